@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/spf13/cobra"
+)
+
+var acceptCmd = &cobra.Command{
+	Use:   "accept <file>",
+	Short: "Ask the node to accept an uploaded file",
+	Long: "Ask the node to accept an uploaded file, and print the link to the " +
+		"location commitment it issues.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+		sp, err := space(cfg)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening file: %w", err)
+		}
+		defer f.Close()
+		blob, err := blobFromFile(f)
+		if err != nil {
+			return err
+		}
+
+		acc, err := c.Accept(cmd.Context(), sp, blob)
+		if err != nil {
+			return fmt.Errorf("accepting blob: %w", err)
+		}
+		cmd.Printf("Location commitment: %s\n", acc.Site)
+
+		out, _ := cmd.Flags().GetString("output")
+		if out == "" {
+			return nil
+		}
+		if acc.LocationCommitment == nil {
+			return fmt.Errorf("the node did not include the location commitment in its response")
+		}
+		data, err := delegation.Encode(acc.LocationCommitment)
+		if err != nil {
+			return fmt.Errorf("encoding location commitment: %w", err)
+		}
+		if err := os.WriteFile(out, data, 0o644); err != nil {
+			return fmt.Errorf("writing location commitment: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	acceptCmd.Flags().StringP("output", "o", "", "File the location commitment is written to")
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/spf13/cobra"
+)
+
+var allocateCmd = &cobra.Command{
+	Use:   "allocate <file>",
+	Short: "Allocate space for a file on the node",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening file: %w", err)
+		}
+		defer f.Close()
+		blob, err := blobFromFile(f)
+		if err != nil {
+			return err
+		}
+
+		alloc, err := allocate(cmd.Context(), cfg, c, blob)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Digest:     %s\n", digestutil.Format(blob.Digest))
+		printAllocation(cmd, alloc)
+		return nil
+	},
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	spaceblobcap "github.com/alanshaw/1up-service/pkg/capabilities/space/blob"
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	logging "github.com/ipfs/go-log/v2"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/volmedo/padron/pkg/client"
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+var log = logging.Logger("cmd/client")
+
+var Cmd = &cobra.Command{
+	Use:   "client",
+	Short: "Interact with a padrón storage node",
+	Long: "Interact with a padrón storage node as an agent of a space. The agent is " +
+		"identified by its own key, and is authorized by a delegation from the space.",
+}
+
+func init() {
+	Cmd.PersistentFlags().String("node-url", "http://localhost:3000", "URL of the node")
+	cobra.CheckErr(viper.BindPFlag("client.url", Cmd.PersistentFlags().Lookup("node-url")))
+
+	Cmd.PersistentFlags().String("node-did", "", "DID of the node")
+	cobra.CheckErr(viper.BindPFlag("client.node_did", Cmd.PersistentFlags().Lookup("node-did")))
+
+	Cmd.PersistentFlags().String("agent-key-file", "", "Path to a file containing the ed25519 private key of the agent, as PEM, JWK or multibase")
+	cobra.CheckErr(Cmd.MarkPersistentFlagFilename("agent-key-file", "pem"))
+	cobra.CheckErr(viper.BindPFlag("client.key_file", Cmd.PersistentFlags().Lookup("agent-key-file")))
+
+	Cmd.PersistentFlags().StringSlice("proof", nil, "Path to a file containing a delegation from the space to the agent (repeatable)")
+	cobra.CheckErr(viper.BindPFlag("client.proofs", Cmd.PersistentFlags().Lookup("proof")))
+
+	Cmd.AddCommand(allocateCmd, uploadCmd, acceptCmd, getCmd, infoCmd)
+}
+
+func loadConfig() (app.ClientConfig, error) {
+	cfg, err := config.Load[config.ClientRootConfig]()
+	if err != nil {
+		return app.ClientConfig{}, fmt.Errorf("loading config: %w", err)
+	}
+	appCfg, err := cfg.Client.ToAppConfig()
+	if err != nil {
+		return app.ClientConfig{}, fmt.Errorf("parsing config: %w", err)
+	}
+	return appCfg, nil
+}
+
+func newClient(cfg app.ClientConfig) (*client.Client, error) {
+	return client.New(cfg.Signer, cfg.Node, cfg.URL, client.WithProofs(cfg.Proofs...))
+}
+
+// space returns the space the agent acts on behalf of, the subject of the
+// first delegation. It fails if the configuration lacks anything needed to
+// invoke the node.
+func space(cfg app.ClientConfig) (ucan.Principal, error) {
+	switch {
+	case cfg.Node == did.DID{}:
+		return nil, errors.New("the node DID is required, set it with --node-did")
+	case cfg.Signer == nil:
+		return nil, errors.New("an agent key is required, set it with --agent-key-file")
+	case len(cfg.Proofs) == 0:
+		return nil, errors.New("a delegation from the space to the agent is required, set it with --proof")
+	}
+	sub := cfg.Proofs[0].Subject()
+	if sub == nil {
+		return nil, fmt.Errorf("delegation %s has no subject, cannot determine the space", cfg.Proofs[0].Link())
+	}
+	return sub, nil
+}
+
+// blobFromFile computes the digest and size of a file.
+func blobFromFile(f *os.File) (client.Blob, error) {
+	h, err := mh.GetHasher(mh.SHA2_256)
+	if err != nil {
+		return client.Blob{}, err
+	}
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return client.Blob{}, fmt.Errorf("reading %s: %w", f.Name(), err)
+	}
+	digest, err := mh.Encode(h.Sum(nil), mh.SHA2_256)
+	if err != nil {
+		return client.Blob{}, err
+	}
+	return client.Blob{Digest: digest, Size: uint64(size)}, nil
+}
+
+// allocate allocates space for the blob, on behalf of a `/space/blob/add`
+// invocation issued by the agent.
+func allocate(ctx context.Context, cfg app.ClientConfig, c *client.Client, blob client.Blob) (*client.Allocation, error) {
+	sp, err := space(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]ucan.Link, 0, len(cfg.Proofs))
+	for _, p := range cfg.Proofs {
+		links = append(links, p.Link())
+	}
+	cause, err := spaceblobcap.Add.Invoke(
+		cfg.Signer,
+		sp,
+		&spaceblobcap.AddArguments{
+			Blob: spaceblobcap.Blob{Digest: blob.Digest, Size: blob.Size},
+		},
+		invocation.WithAudience(cfg.Node),
+		invocation.WithProofs(links...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating add invocation: %w", err)
+	}
+
+	alloc, err := c.Allocate(ctx, sp, blob, cause)
+	if err != nil {
+		return nil, fmt.Errorf("allocating blob: %w", err)
+	}
+	log.Debugw("allocated blob", "digest", digestutil.Format(blob.Digest), "size", alloc.Size)
+	return alloc, nil
+}
+
+func printAllocation(cmd *cobra.Command, alloc *client.Allocation) {
+	cmd.Printf("Allocated: %d bytes\n", alloc.Size)
+	if alloc.Address == nil {
+		cmd.Println("The node already holds the blob")
+		return
+	}
+	cmd.Printf("Upload URL: %s\n", alloc.Address.URL)
+	for k, vv := range alloc.Address.Headers {
+		for _, v := range vv {
+			cmd.Printf("Header:     %s: %s\n", k, v)
+		}
+	}
+	cmd.Printf("Expires:    %s\n", alloc.Address.Expires.Format(time.RFC3339))
+}
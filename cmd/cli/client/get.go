@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <digest>",
+	Short: "Retrieve a blob from the node",
+	Long:  "Retrieve a blob from the node and write it to stdout, or to the file given with --output.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		digest, err := digestutil.Parse(args[0])
+		if err != nil {
+			return fmt.Errorf("parsing digest: %w", err)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		body, err := c.Retrieve(cmd.Context(), digest)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		w := cmd.OutOrStdout()
+		if out, _ := cmd.Flags().GetString("output"); out != "" {
+			f, err := os.Create(out)
+			if err != nil {
+				return fmt.Errorf("creating output file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if _, err := io.Copy(w, body); err != nil {
+			return fmt.Errorf("reading blob: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	getCmd.Flags().StringP("output", "o", "", "File the blob is written to")
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Print the information the node publishes about itself",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		info, err := c.Info(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("fetching node info: %w", err)
+		}
+		if cfg.Node != (did.DID{}) && info.ID != cfg.Node.String() {
+			log.Warnw("node identifies with a different DID", "expected", cfg.Node, "actual", info.ID)
+		}
+
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	},
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/spf13/cobra"
+)
+
+var uploadCmd = &cobra.Command{
+	Use:   "upload <file>",
+	Short: "Allocate space for a file and upload it to the node",
+	Long: "Allocate space for a file and upload it to the node. Use the accept " +
+		"command afterwards to obtain a location commitment for it.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening file: %w", err)
+		}
+		defer f.Close()
+		blob, err := blobFromFile(f)
+		if err != nil {
+			return err
+		}
+
+		alloc, err := allocate(cmd.Context(), cfg, c, blob)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Digest: %s\n", digestutil.Format(blob.Digest))
+		if alloc.Address == nil {
+			cmd.Println("The node already holds the blob")
+			return nil
+		}
+
+		if err := c.Upload(cmd.Context(), alloc.Address, f, blob.Size); err != nil {
+			return fmt.Errorf("uploading blob: %w", err)
+		}
+		cmd.Printf("Uploaded %d bytes\n", blob.Size)
+		return nil
+	},
+}
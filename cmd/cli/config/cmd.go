@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/volmedo/padron/cmd/cli/serve"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration of the node",
+	Long: "Manage the configuration of the node. Config is merged from command line flags, " +
+		"PADRON_* environment variables and a TOML file, in that order of precedence.",
+}
+
+func init() {
+	Cmd.AddCommand(initCmd, validateCmd, showCmd)
+}
+
+// flags returns the flags that can set config keys, those of the command
+// itself, inherited from the root command, and those of the serve command.
+func flags(cmd *cobra.Command) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	fs.AddFlagSet(cmd.Flags())
+	fs.AddFlagSet(serve.Cmd.PersistentFlags())
+	return fs
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/volmedo/padron/pkg/config"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [path]",
+	Short: "Write a commented config file with the default settings",
+	Long: "Write a commented config file with the default settings, to path or to the " +
+		"default config file in the user config directory. Use - to print it instead.",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := config.DefaultTOML(flags(cmd))
+		if err != nil {
+			return fmt.Errorf("rendering config: %w", err)
+		}
+
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		} else if path, err = config.DefaultFilePath(); err != nil {
+			return fmt.Errorf("finding user config directory: %w", err)
+		}
+		if path == "-" {
+			_, err := cmd.OutOrStdout().Write(data)
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("creating config directory: %w", err)
+		}
+		flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if force, _ := cmd.Flags().GetBool("force"); force {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(path, flag, 0600)
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("config file %s already exists, use --force to overwrite it", path)
+			}
+			return fmt.Errorf("creating config file: %w", err)
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return fmt.Errorf("writing config file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("writing config file: %w", err)
+		}
+
+		cmd.Printf("Config written to %s\n", path)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().Bool("force", false, "Overwrite the config file if it exists")
+}
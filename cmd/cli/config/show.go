@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/volmedo/padron/pkg/config"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective config and where each value came from",
+	Long: "Print the effective config, merged from flags, environment variables, the " +
+		"config file and defaults, with the source of each value. Secrets are redacted.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := config.Decode[config.Config]()
+		if err != nil {
+			return fmt.Errorf("decoding config: %w", err)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		for _, s := range config.Settings(cfg, flags(cmd)) {
+			value, err := config.FormatValue(s.Value)
+			if err != nil {
+				return fmt.Errorf("formatting %s: %w", s.Key, err)
+			}
+			fmt.Fprintf(w, "%s = %s\t# %s\n", s.Key, value, s.Source)
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/volmedo/padron/pkg/config"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config without starting the server",
+	Long: "Check the config without starting the server. Besides validating every key, " +
+		"this loads the identity key and parses the values the server would use.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		userCfg, err := config.Load[config.Config]()
+		if err != nil {
+			return err
+		}
+		appCfg, err := userCfg.ToAppConfig()
+		if err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+		if c, ok := appCfg.Identity.Key().(io.Closer); ok {
+			c.Close()
+		}
+
+		if file := viper.ConfigFileUsed(); file != "" {
+			cmd.Printf("Config is valid (%s)\n", file)
+		} else {
+			cmd.Println("Config is valid")
+		}
+		cmd.Println(appCfg.Identity.Signer.DID())
+		return nil
+	},
+}
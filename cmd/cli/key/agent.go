@@ -0,0 +1,60 @@
+package key
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/keyagent"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run a key agent that signs on behalf of the node",
+	Long: "Run a key agent holding the identity key, so the node can be started with " +
+		"--signer agent and never load the key itself. The agent listens on the " +
+		"Unix socket given by --key-agent-socket, accessible only to its owner " +
+		"unless --socket-mode and --socket-group grant access to a group.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cfg, err := config.Load[config.IdentityRootConfig]()
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		if cfg.Identity.AgentSocket == "" {
+			return fmt.Errorf("identity.agent_socket is required, set it with --key-agent-socket")
+		}
+
+		// the agent holds the key itself
+		keyCfg := cfg.Identity
+		keyCfg.Signer = "key"
+		id, err := keyCfg.LoadSigner()
+		if err != nil {
+			return err
+		}
+
+		options, err := cfg.Identity.AgentListenOptions()
+		if err != nil {
+			return err
+		}
+		l, err := keyagent.Listen(cfg.Identity.AgentSocket, options...)
+		if err != nil {
+			return fmt.Errorf("listening on key agent socket: %w", err)
+		}
+		defer os.Remove(cfg.Identity.AgentSocket)
+
+		cmd.Printf("🔑 Key agent for %s listening on %s\n", id.DID(), cfg.Identity.AgentSocket)
+		return keyagent.NewServer(id).Serve(cmd.Context(), l)
+	},
+}
+
+func init() {
+	agentCmd.Flags().String("socket-mode", "0600", "Permissions of the key agent socket, in octal")
+	cobra.CheckErr(viper.BindPFlag("identity.agent_socket_mode", agentCmd.Flags().Lookup("socket-mode")))
+
+	agentCmd.Flags().String("socket-group", "", "Group owning the key agent socket, by name or ID")
+	cobra.CheckErr(viper.BindPFlag("identity.agent_socket_group", agentCmd.Flags().Lookup("socket-group")))
+}
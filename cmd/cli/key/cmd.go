@@ -0,0 +1,41 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/volmedo/padron/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the identity key of the node",
+	Long: "Manage the identity key of the node. Keys are written as PKCS#8 PEM files " +
+		"at the path given by --key-file (identity.key_file).",
+}
+
+func init() {
+	Cmd.AddCommand(generateCmd, showCmd, importCmd, exportCmd, agentCmd)
+}
+
+// keyFile returns the configured path of the identity key.
+func keyFile() (string, error) {
+	path := viper.GetString("identity.key_file")
+	if path == "" {
+		return "", errors.New("identity.key_file is required, set it with --key-file")
+	}
+	return path, nil
+}
+
+// loadSigner loads the configured identity key, in any supported encoding.
+func loadSigner() (principal.Signer, error) {
+	cfg, err := config.Load[config.IdentityRootConfig]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg.Identity.LoadSigner()
+}
@@ -0,0 +1,59 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/multiformats/go-multibase"
+	"github.com/spf13/cobra"
+
+	"github.com/volmedo/padron/pkg/config/lib"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the identity key",
+	Long: "Print the identity key, as a PKCS#8 PEM, a JWK or a multibase encoded " +
+		"private key. The output contains the private key, handle it with care.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		id, err := loadSigner()
+		if err != nil {
+			return err
+		}
+		if id.Raw() == nil {
+			return errors.New("the key is held by a key agent and cannot be exported")
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		switch format {
+		case "pem":
+			data, err := lib.EncodeEd25519PEM(id)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		case "jwk":
+			data, err := lib.EncodeEd25519JWK(id)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		case "multibase":
+			str, err := multibase.Encode(multibase.Base64pad, id.Bytes())
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), str)
+			return err
+		default:
+			return fmt.Errorf("unknown key format %q, expected pem, jwk or multibase", format)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("format", "pem", "Output format: pem, jwk or multibase")
+}
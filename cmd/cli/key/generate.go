@@ -0,0 +1,39 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/spf13/cobra"
+
+	"github.com/volmedo/padron/pkg/config/lib"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a new ed25519 identity key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		path, err := keyFile()
+		if err != nil {
+			return err
+		}
+
+		id, err := ed25519.Generate()
+		if err != nil {
+			return err
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if err := lib.WriteEd25519PEMFile(path, id, force); err != nil {
+			return fmt.Errorf("writing key file: %w", err)
+		}
+
+		cmd.Printf("Key written to %s\n", path)
+		cmd.Println(id.DID())
+		return nil
+	},
+}
+
+func init() {
+	generateCmd.Flags().Bool("force", false, "Overwrite the key file if it exists")
+}
@@ -0,0 +1,67 @@
+package key
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/lib"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import an ed25519 identity key",
+	Long: "Import an ed25519 identity key into the key file. The key is read from the " +
+		"given file, or stdin if it is \"-\", as a PKCS#8 PEM (e.g. created with " +
+		"`openssl genpkey -algorithm ed25519`), an encrypted PKCS#8 PEM decrypted " +
+		"with --key-passphrase-file, a JWK or a multibase encoded private key, like " +
+		"other Storacha tools emit. The key file is always written as an " +
+		"unencrypted PEM.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := keyFile()
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		if args[0] == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("reading key: %w", err)
+		}
+
+		// encrypted keys are decrypted with the passphrase of the identity key,
+		// set with --key-passphrase-file or PADRON_IDENTITY_PASSPHRASE
+		cfg, err := config.Load[config.IdentityRootConfig]()
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		passphrase, err := cfg.Identity.LoadPassphrase()
+		if err != nil {
+			return err
+		}
+		id, err := lib.ParseSigner(data, passphrase)
+		if err != nil {
+			return fmt.Errorf("parsing key: %w", err)
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if err := lib.WriteEd25519PEMFile(path, id, force); err != nil {
+			return fmt.Errorf("writing key file: %w", err)
+		}
+
+		cmd.Printf("Key written to %s\n", path)
+		cmd.Println(id.DID())
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().Bool("force", false, "Overwrite the key file if it exists")
+}
@@ -0,0 +1,19 @@
+package key
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the DID of the identity key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		id, err := loadSigner()
+		if err != nil {
+			return err
+		}
+		cmd.Println(id.DID())
+		return nil
+	},
+}
@@ -3,7 +3,6 @@ package cli
 import (
 	"context"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
@@ -12,8 +11,13 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/volmedo/padron/cmd/cli/client"
+	configcmd "github.com/volmedo/padron/cmd/cli/config"
+	"github.com/volmedo/padron/cmd/cli/key"
 	"github.com/volmedo/padron/cmd/cli/serve"
 	"github.com/volmedo/padron/pkg/build"
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/logs"
 )
 
 func ExecuteContext(ctx context.Context) {
@@ -24,12 +28,9 @@ func ExecuteContext(ctx context.Context) {
 
 var log = logging.Logger("cmd")
 
-var configFilePath = path.Join("padron", "config.toml")
-
 var (
-	cfgFile  string
-	logLevel string
-	rootCmd  = &cobra.Command{
+	cfgFile string
+	rootCmd = &cobra.Command{
 		Use:     "padron",
 		Short:   "A storage node for the Storacha Network",
 		Long:    "A UCAN 1.0-enabled storage node for the Storacha Network",
@@ -38,9 +39,9 @@ var (
 )
 
 func init() {
-	cobra.OnInitialize(initLogging, initConfig)
+	cobra.OnInitialize(initConfig, initLogging)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file path. Attempts to load from user config directory if not set e.g. ~/.config/"+configFilePath)
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file path. Attempts to load from user config directory if not set e.g. ~/.config/padron/config.toml")
 
 	rootCmd.PersistentFlags().String("data-dir", filepath.Join(lo.Must(os.UserHomeDir()), ".padron", "data"), "Padrón data directory")
 	cobra.CheckErr(viper.BindPFlag("stores.data_dir", rootCmd.PersistentFlags().Lookup("data-dir")))
@@ -48,22 +49,61 @@ func init() {
 	rootCmd.PersistentFlags().String("temp-dir", filepath.Join(lo.Must(os.UserHomeDir()), ".padron", "temp"), "Padrón temporary directory")
 	cobra.CheckErr(viper.BindPFlag("stores.temp_dir", rootCmd.PersistentFlags().Lookup("temp-dir")))
 
-	rootCmd.PersistentFlags().String("key-file", "", "Path to a PEM file containing ed25519 private key")
-	cobra.CheckErr(rootCmd.MarkPersistentFlagFilename("key-file", "pem"))
+	rootCmd.PersistentFlags().String("key-file", "", "Path to a file containing an ed25519 private key, as PEM, JWK or multibase")
+	cobra.CheckErr(rootCmd.MarkPersistentFlagFilename("key-file", "pem", "json", "key"))
 	cobra.CheckErr(viper.BindPFlag("identity.key_file", rootCmd.PersistentFlags().Lookup("key-file")))
 
+	rootCmd.PersistentFlags().String("signer", "key", "Where the identity key is held: key (loaded from --key-file) or agent (a key agent at --key-agent-socket)")
+	cobra.CheckErr(viper.BindPFlag("identity.signer", rootCmd.PersistentFlags().Lookup("signer")))
+
+	rootCmd.PersistentFlags().String("key-agent-socket", "", "Path to the Unix socket of the key agent")
+	cobra.CheckErr(viper.BindPFlag("identity.agent_socket", rootCmd.PersistentFlags().Lookup("key-agent-socket")))
+
+	rootCmd.PersistentFlags().String("key-passphrase-file", "", "Path to a file containing the passphrase of an encrypted PEM key")
+	cobra.CheckErr(viper.BindPFlag("identity.passphrase_file", rootCmd.PersistentFlags().Lookup("key-passphrase-file")))
+
+	rootCmd.PersistentFlags().String("did-web", "", "did:web identity of the node, backed by its key, e.g. did:web:storage.example.com")
+	cobra.CheckErr(viper.BindPFlag("identity.did_web", rootCmd.PersistentFlags().Lookup("did-web")))
+
+	rootCmd.PersistentFlags().String("log-level", "warn", "Log level of all subsystems: debug, info, warn or error")
+	cobra.CheckErr(viper.BindPFlag("logging.level", rootCmd.PersistentFlags().Lookup("log-level")))
+
+	rootCmd.PersistentFlags().StringToString("log-subsystem-level", map[string]string{"cmd/serve": "info"}, "Log level of specific subsystems, e.g. service/blob=debug")
+	cobra.CheckErr(viper.BindPFlag("logging.subsystems", rootCmd.PersistentFlags().Lookup("log-subsystem-level")))
+
+	rootCmd.PersistentFlags().String("log-format", "console", "Log format: console or json")
+	cobra.CheckErr(viper.BindPFlag("logging.format", rootCmd.PersistentFlags().Lookup("log-format")))
+
+	rootCmd.PersistentFlags().String("log-file", "", "File logs are written to instead of stderr")
+	cobra.CheckErr(viper.BindPFlag("logging.file", rootCmd.PersistentFlags().Lookup("log-file")))
+
+	rootCmd.PersistentFlags().Int("log-max-size", 100, "Size in megabytes at which the log file is rotated")
+	cobra.CheckErr(viper.BindPFlag("logging.max_size", rootCmd.PersistentFlags().Lookup("log-max-size")))
+
+	rootCmd.PersistentFlags().Int("log-max-backups", 3, "Number of rotated log files kept (0 keeps all)")
+	cobra.CheckErr(viper.BindPFlag("logging.max_backups", rootCmd.PersistentFlags().Lookup("log-max-backups")))
+
+	rootCmd.PersistentFlags().Int("log-max-age", 28, "Number of days rotated log files are kept (0 keeps them forever)")
+	cobra.CheckErr(viper.BindPFlag("logging.max_age", rootCmd.PersistentFlags().Lookup("log-max-age")))
+
+	rootCmd.PersistentFlags().Bool("log-compress", false, "Compress rotated log files")
+	cobra.CheckErr(viper.BindPFlag("logging.compress", rootCmd.PersistentFlags().Lookup("log-compress")))
+
 	// register all commands and their subcommands
 	rootCmd.AddCommand(serve.Cmd)
+	rootCmd.AddCommand(client.Cmd)
+	rootCmd.AddCommand(key.Cmd)
+	rootCmd.AddCommand(configcmd.Cmd)
 }
 
 func initConfig() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.SetEnvPrefix("PADRON")
+	cobra.CheckErr(config.BindEnv(config.Config{}, config.ClientRootConfig{}))
 
 	if cfgFile == "" {
-		if configDir, err := os.UserConfigDir(); err == nil {
-			defaultCfgFile := path.Join(configDir, configFilePath)
+		if defaultCfgFile, err := config.DefaultFilePath(); err == nil {
 			if inf, err := os.Stat(defaultCfgFile); err == nil && !inf.IsDir() {
 				log.Infof("loading config automatically from: %s", defaultCfgFile)
 				cfgFile = defaultCfgFile
@@ -87,13 +127,9 @@ func initConfig() {
 }
 
 func initLogging() {
-	if logLevel != "" {
-		ll, err := logging.LevelFromString(logLevel)
-		cobra.CheckErr(err)
-		logging.SetAllLoggers(ll)
-	} else {
-		// else set all loggers to warn level, then the ones we care most about to info.
-		logging.SetAllLoggers(logging.LevelWarn)
-		logging.SetLogLevel("cmd/serve", "info")
-	}
+	cfg, err := config.Load[config.LoggingRootConfig]()
+	cobra.CheckErr(err)
+	appCfg, err := cfg.Logging.ToAppConfig()
+	cobra.CheckErr(err)
+	cobra.CheckErr(logs.Setup(appCfg))
 }
@@ -3,6 +3,7 @@ package serve
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	logging "github.com/ipfs/go-log/v2"
@@ -16,8 +17,13 @@ import (
 	"github.com/volmedo/padron/pkg/build"
 	"github.com/volmedo/padron/pkg/config"
 	"github.com/volmedo/padron/pkg/fx/app"
+	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	reloadfx "github.com/volmedo/padron/pkg/fx/reload"
 	"github.com/volmedo/padron/pkg/fx/root"
+	"github.com/volmedo/padron/pkg/fx/systemd"
 	"github.com/volmedo/padron/pkg/fx/ucan"
+	"github.com/volmedo/padron/pkg/listener"
+	"github.com/volmedo/padron/pkg/reload"
 )
 
 var log = logging.Logger("cmd/serve")
@@ -27,7 +33,7 @@ var Cmd = &cobra.Command{
 	Short: "Start the padrón storage node!",
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		userCfg, err := config.Load[config.Config]()
+		userCfg, err := loadConfig()
 		if err != nil {
 			return fmt.Errorf("loading config: %w", err)
 		}
@@ -57,12 +63,21 @@ var Cmd = &cobra.Command{
 			//   - databases & datastores
 			app.CommonModules(appCfg),
 
+			// the config the node was started with, and how to load it again
+			// when reloading it on SIGHUP or when the config file changes.
+			fx.Supply(userCfg),
+			fx.Supply(reload.Loader(loadConfig)),
+			fx.Supply(watchedConfigFile(cmd)),
+
 			root.Module,
 
 			ucan.Module,
 
+			// report readiness once every server is started
+			systemd.Module,
+
 			// Post-startup operations: print server info and record telemetry
-			fx.Invoke(func(lc fx.Lifecycle) {
+			fx.Invoke(func(srv *echofx.EchoServer, lc fx.Lifecycle) {
 				lc.Append(fx.Hook{
 					OnStart: func(ctx context.Context) error {
 						cmd.Println("")
@@ -73,7 +88,21 @@ var Cmd = &cobra.Command{
 						cmd.Println("")
 						cmd.Printf("🫑 padrón %s\n", build.Version)
 						cmd.Printf("🆔 %s\n", appCfg.Identity.Signer.DID())
-						cmd.Printf("🚀 Ready! Server running on: http://%s:%d\n", appCfg.Server.Host, appCfg.Server.Port)
+						scheme := "http"
+						if appCfg.Server.TLS.Enabled() {
+							scheme = "https"
+						}
+						// the addresses actually bound, e.g. the port picked for :0
+						// or the sockets passed by systemd
+						var addrs []string
+						for _, addr := range srv.Addresses() {
+							if strings.HasPrefix(addr, listener.UnixPrefix) {
+								addrs = append(addrs, addr)
+							} else {
+								addrs = append(addrs, scheme+"://"+addr)
+							}
+						}
+						cmd.Printf("🚀 Ready! Server running on: %s\n", strings.Join(addrs, ", "))
 						return nil
 					},
 					OnStop: func(ctx context.Context) error {
@@ -97,7 +126,32 @@ var Cmd = &cobra.Command{
 	},
 }
 
+// watchedConfigFile is the config file to reload when it changes, if it is
+// watched.
+func watchedConfigFile(cmd *cobra.Command) reloadfx.ConfigFile {
+	if watch, _ := cmd.Flags().GetBool("watch-config"); watch {
+		return reloadfx.ConfigFile(viper.ConfigFileUsed())
+	}
+	return ""
+}
+
+// loadConfig reads the config file again, if any, and loads the config.
+func loadConfig() (config.Config, error) {
+	if viper.ConfigFileUsed() != "" {
+		if err := viper.ReadInConfig(); err != nil {
+			return config.Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+	}
+	return config.Load[config.Config]()
+}
+
 func init() {
+	Cmd.Flags().Bool(
+		"watch-config",
+		false,
+		"Reload the config when the config file changes, as on SIGHUP",
+	)
+
 	Cmd.PersistentFlags().String(
 		"host",
 		"localhost",
@@ -111,10 +165,238 @@ func init() {
 	)
 	cobra.CheckErr(viper.BindPFlag("server.port", Cmd.PersistentFlags().Lookup("port")))
 
+	Cmd.PersistentFlags().StringSlice(
+		"listen",
+		nil,
+		"Address to listen on instead of host and port: host:port, unix:/path/to/socket, systemd or systemd:name (can be repeated)",
+	)
+	cobra.CheckErr(viper.BindPFlag("server.listen", Cmd.PersistentFlags().Lookup("listen")))
+
+	Cmd.PersistentFlags().String(
+		"unix-socket-mode",
+		"",
+		"Permissions of the Unix sockets listened on, in octal, e.g. 0660 (defaults to the umask)",
+	)
+	cobra.CheckErr(viper.BindPFlag("server.unix_socket_mode", Cmd.PersistentFlags().Lookup("unix-socket-mode")))
+
 	Cmd.PersistentFlags().String(
 		"public-url",
 		"http://localhost:3000",
 		"Public URL for the server",
 	)
 	cobra.CheckErr(viper.BindPFlag("server.public_url", Cmd.PersistentFlags().Lookup("public-url")))
+
+	Cmd.PersistentFlags().StringSlice(
+		"mirror-url",
+		nil,
+		"Additional public URL blobs can be retrieved from, e.g. a CDN (can be repeated)",
+	)
+	cobra.CheckErr(viper.BindPFlag("server.mirror_urls", Cmd.PersistentFlags().Lookup("mirror-url")))
+
+	Cmd.PersistentFlags().String(
+		"tls-cert-file",
+		"",
+		"PEM certificate file to serve over TLS, reloaded when it changes",
+	)
+	cobra.CheckErr(Cmd.MarkPersistentFlagFilename("tls-cert-file", "pem", "crt"))
+	cobra.CheckErr(viper.BindPFlag("server.tls.cert_file", Cmd.PersistentFlags().Lookup("tls-cert-file")))
+
+	Cmd.PersistentFlags().String(
+		"tls-key-file",
+		"",
+		"PEM private key file of the TLS certificate",
+	)
+	cobra.CheckErr(Cmd.MarkPersistentFlagFilename("tls-key-file", "pem", "key"))
+	cobra.CheckErr(viper.BindPFlag("server.tls.key_file", Cmd.PersistentFlags().Lookup("tls-key-file")))
+
+	Cmd.PersistentFlags().String(
+		"tls-client-ca-file",
+		"",
+		"PEM file of the CAs whose client certificates are required by the admin endpoints",
+	)
+	cobra.CheckErr(Cmd.MarkPersistentFlagFilename("tls-client-ca-file", "pem", "crt"))
+	cobra.CheckErr(viper.BindPFlag("server.tls.client_ca_file", Cmd.PersistentFlags().Lookup("tls-client-ca-file")))
+
+	Cmd.PersistentFlags().Bool(
+		"h2c",
+		false,
+		"Accept HTTP/2 without TLS, e.g. from a reverse proxy",
+	)
+	cobra.CheckErr(viper.BindPFlag("server.h2c", Cmd.PersistentFlags().Lookup("h2c")))
+
+	Cmd.PersistentFlags().Duration(
+		"http-read-header-timeout",
+		10*time.Second,
+		"Time allowed to read the headers of a request",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.read_header_timeout", Cmd.PersistentFlags().Lookup("http-read-header-timeout")))
+
+	Cmd.PersistentFlags().Duration(
+		"http-read-timeout",
+		time.Minute,
+		"Time allowed to read an API request, including its body",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.read_timeout", Cmd.PersistentFlags().Lookup("http-read-timeout")))
+
+	Cmd.PersistentFlags().Duration(
+		"http-write-timeout",
+		time.Minute,
+		"Time allowed to write an API response",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.write_timeout", Cmd.PersistentFlags().Lookup("http-write-timeout")))
+
+	Cmd.PersistentFlags().Duration(
+		"http-idle-timeout",
+		2*time.Minute,
+		"How long idle keep-alive connections are kept open",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.idle_timeout", Cmd.PersistentFlags().Lookup("http-idle-timeout")))
+
+	Cmd.PersistentFlags().Int(
+		"http-max-header-bytes",
+		1<<20,
+		"Maximum size of the headers of a request, in bytes",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.max_header_bytes", Cmd.PersistentFlags().Lookup("http-max-header-bytes")))
+
+	Cmd.PersistentFlags().Int64(
+		"ucan-body-limit",
+		8<<20,
+		"Maximum size of the UCAN container of an invocation, in bytes",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.ucan_body_limit", Cmd.PersistentFlags().Lookup("ucan-body-limit")))
+
+	Cmd.PersistentFlags().Duration(
+		"upload-timeout",
+		time.Hour,
+		"Time allowed to complete a blob upload, instead of the API timeouts",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.upload_timeout", Cmd.PersistentFlags().Lookup("upload-timeout")))
+
+	Cmd.PersistentFlags().Duration(
+		"download-timeout",
+		time.Hour,
+		"Time allowed to write a blob download, instead of the API write timeout",
+	)
+	cobra.CheckErr(viper.BindPFlag("http.download_timeout", Cmd.PersistentFlags().Lookup("download-timeout")))
+
+	Cmd.PersistentFlags().Float64(
+		"rate-limit-did",
+		10,
+		"UCAN invocations per second allowed for each issuer DID, 0 disables the limit",
+	)
+	cobra.CheckErr(viper.BindPFlag("rate_limit.did_rate", Cmd.PersistentFlags().Lookup("rate-limit-did")))
+
+	Cmd.PersistentFlags().Int(
+		"rate-limit-did-burst",
+		50,
+		"UCAN invocations allowed in a burst for each issuer DID",
+	)
+	cobra.CheckErr(viper.BindPFlag("rate_limit.did_burst", Cmd.PersistentFlags().Lookup("rate-limit-did-burst")))
+
+	Cmd.PersistentFlags().Float64(
+		"rate-limit-ip",
+		20,
+		"Blob and invocation requests per second allowed for each client IP, 0 disables the limit",
+	)
+	cobra.CheckErr(viper.BindPFlag("rate_limit.ip_rate", Cmd.PersistentFlags().Lookup("rate-limit-ip")))
+
+	Cmd.PersistentFlags().Int(
+		"rate-limit-ip-burst",
+		100,
+		"Blob and invocation requests allowed in a burst for each client IP",
+	)
+	cobra.CheckErr(viper.BindPFlag("rate_limit.ip_burst", Cmd.PersistentFlags().Lookup("rate-limit-ip-burst")))
+
+	Cmd.PersistentFlags().Bool(
+		"rate-limit-trust-forwarded",
+		false,
+		"Take the client IP from the X-Forwarded-For and X-Real-IP headers, only behind a trusted reverse proxy",
+	)
+	cobra.CheckErr(viper.BindPFlag("rate_limit.trust_forwarded", Cmd.PersistentFlags().Lookup("rate-limit-trust-forwarded")))
+
+	Cmd.PersistentFlags().Duration(
+		"clock-skew",
+		0,
+		"Clock drift tolerated when validating UCAN time bounds",
+	)
+	cobra.CheckErr(viper.BindPFlag("ucan.clock_skew", Cmd.PersistentFlags().Lookup("clock-skew")))
+
+	Cmd.PersistentFlags().Duration(
+		"commitment-ttl",
+		30*24*time.Hour,
+		"Lifetime of the location commitments issued for accepted blobs",
+	)
+	cobra.CheckErr(viper.BindPFlag("blob.commitment_ttl", Cmd.PersistentFlags().Lookup("commitment-ttl")))
+
+	Cmd.PersistentFlags().Duration(
+		"commitment-renew-interval",
+		time.Hour,
+		"How often expiring location commitments are renewed",
+	)
+	cobra.CheckErr(viper.BindPFlag("blob.renew_interval", Cmd.PersistentFlags().Lookup("commitment-renew-interval")))
+
+	Cmd.PersistentFlags().Duration(
+		"commitment-renew-before",
+		7*24*time.Hour,
+		"How long before their expiration location commitments are renewed",
+	)
+	cobra.CheckErr(viper.BindPFlag("blob.renew_before", Cmd.PersistentFlags().Lookup("commitment-renew-before")))
+
+	Cmd.PersistentFlags().String(
+		"metrics-addr",
+		"",
+		"Address of a separate listener for the metrics endpoint (defaults to the main server)",
+	)
+	cobra.CheckErr(viper.BindPFlag("metrics.addr", Cmd.PersistentFlags().Lookup("metrics-addr")))
+
+	Cmd.PersistentFlags().String(
+		"tracing-exporter",
+		"none",
+		"Exporter for OpenTelemetry traces: none, stdout, file or otlp",
+	)
+	cobra.CheckErr(viper.BindPFlag("tracing.exporter", Cmd.PersistentFlags().Lookup("tracing-exporter")))
+
+	Cmd.PersistentFlags().String(
+		"tracing-file",
+		"",
+		"File traces are written to by the file exporter",
+	)
+	cobra.CheckErr(viper.BindPFlag("tracing.file", Cmd.PersistentFlags().Lookup("tracing-file")))
+
+	Cmd.PersistentFlags().String(
+		"tracing-endpoint",
+		"",
+		"Collector URL for the otlp exporter (defaults to the OTEL_EXPORTER_OTLP_* environment variables)",
+	)
+	cobra.CheckErr(viper.BindPFlag("tracing.endpoint", Cmd.PersistentFlags().Lookup("tracing-endpoint")))
+
+	Cmd.PersistentFlags().Float64(
+		"tracing-sample-ratio",
+		1,
+		"Fraction of traces sampled, between 0 and 1",
+	)
+	cobra.CheckErr(viper.BindPFlag("tracing.sample_ratio", Cmd.PersistentFlags().Lookup("tracing-sample-ratio")))
+
+	Cmd.PersistentFlags().Uint64(
+		"min-free-space",
+		1<<30,
+		"Bytes that must be available in the data directory for the node to report itself as ready",
+	)
+	cobra.CheckErr(viper.BindPFlag("health.min_free_space", Cmd.PersistentFlags().Lookup("min-free-space")))
+
+	Cmd.PersistentFlags().Duration(
+		"drain-delay",
+		0,
+		"How long to keep serving requests after readiness starts failing on shutdown",
+	)
+	cobra.CheckErr(viper.BindPFlag("health.drain_delay", Cmd.PersistentFlags().Lookup("drain-delay")))
+
+	Cmd.PersistentFlags().String(
+		"admin-token-file",
+		"",
+		"File holding the bearer token required by the admin endpoints, which are disabled without a token",
+	)
+	cobra.CheckErr(Cmd.MarkPersistentFlagFilename("admin-token-file"))
+	cobra.CheckErr(viper.BindPFlag("admin.token_file", Cmd.PersistentFlags().Lookup("admin-token-file")))
 }
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	blobcap "github.com/alanshaw/libracha/capabilities/blob"
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+
+	"github.com/volmedo/padron/pkg/build"
+)
+
+// Blob identifies a blob by its digest and size in bytes.
+type Blob struct {
+	Digest mh.Multihash
+	Size   uint64
+}
+
+// Address is where and how a blob must be uploaded.
+type Address struct {
+	URL     *url.URL
+	Headers http.Header
+	Expires time.Time
+}
+
+// Allocation is the outcome of allocating space for a blob.
+type Allocation struct {
+	// Size is the number of bytes allocated. It is zero if the space already
+	// had an allocation for the blob.
+	Size uint64
+	// Address is where the blob must be uploaded, or nil if the node already
+	// holds it.
+	Address *Address
+}
+
+// Acceptance is the outcome of accepting an uploaded blob.
+type Acceptance struct {
+	// Site is the link to the location commitment issued by the node.
+	Site cid.Cid
+	// LocationCommitment is the location commitment, if the node included it
+	// in the response.
+	LocationCommitment ucan.Delegation
+}
+
+// Allocate allocates space for the blob in the given space. The cause is the
+// invocation that caused the allocation, e.g. a `/space/blob/add`, and is sent
+// along with the allocation.
+func (c *Client) Allocate(ctx context.Context, space ucan.Principal, blob Blob, cause ucan.Invocation) (*Allocation, error) {
+	inv, err := blobcap.Allocate.Invoke(
+		c.id,
+		space,
+		&blobcap.AllocateArguments{
+			Blob:  blobcap.Blob{Digest: blob.Digest, Size: blob.Size},
+			Cause: cause.Link(),
+		},
+		c.invocationOptions()...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating allocate invocation: %w", err)
+	}
+
+	o, _, err := c.execute(ctx, inv, cause)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := blobcap.AllocateOK{}
+	if err := datamodel.Rebind(datamodel.NewAny(o), &ok); err != nil {
+		return nil, fmt.Errorf("decoding allocate result: %w", err)
+	}
+
+	alloc := &Allocation{Size: ok.Size}
+	if ok.Address != nil {
+		headers := http.Header{}
+		for k, v := range ok.Address.Headers {
+			headers.Set(k, v)
+		}
+		alloc.Address = &Address{
+			URL:     ok.Address.URL.URL(),
+			Headers: headers,
+			Expires: ok.Address.Expires.Time(),
+		}
+	}
+	return alloc, nil
+}
+
+// Upload puts the blob data at the address returned by [Client.Allocate]. The
+// data is read from the start again if the upload has to be retried.
+func (c *Client) Upload(ctx context.Context, address *Address, data io.ReadSeeker, size uint64) error {
+	return c.retry(ctx, func() error {
+		if _, err := data.Seek(0, io.SeekStart); err != nil {
+			return permanent(fmt.Errorf("rewinding blob data: %w", err))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, address.URL.String(), io.NopCloser(data))
+		if err != nil {
+			return permanent(err)
+		}
+		for k, vv := range address.Headers {
+			for _, v := range vv {
+				req.Header.Add(k, v)
+			}
+		}
+		req.ContentLength = int64(size)
+		req.Header.Set("User-Agent", build.UserAgent)
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		return checkStatus(res)
+	})
+}
+
+// Accept asks the node to accept an uploaded blob, and returns the location
+// commitment it issues for it.
+func (c *Client) Accept(ctx context.Context, space ucan.Principal, blob Blob) (*Acceptance, error) {
+	inv, err := blobcap.Accept.Invoke(
+		c.id,
+		space,
+		&blobcap.AcceptArguments{
+			Blob: blobcap.Blob{Digest: blob.Digest, Size: blob.Size},
+		},
+		c.invocationOptions()...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating accept invocation: %w", err)
+	}
+
+	o, res, err := c.execute(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := blobcap.AcceptOK{}
+	if err := datamodel.Rebind(datamodel.NewAny(o), &ok); err != nil {
+		return nil, fmt.Errorf("decoding accept result: %w", err)
+	}
+
+	acc := &Acceptance{Site: ok.Site}
+	if res.Metadata() != nil {
+		for _, d := range res.Metadata().Delegations() {
+			if d.Link() == ok.Site {
+				acc.LocationCommitment = d
+				break
+			}
+		}
+	}
+	return acc, nil
+}
+
+// Retrieve reads a blob from the node. It returns [ErrNotFound] if the node
+// does not hold the blob. The caller must close the returned reader.
+func (c *Client) Retrieve(ctx context.Context, digest mh.Multihash) (io.ReadCloser, error) {
+	u := c.serviceURL.JoinPath("blob", digestutil.Format(digest))
+
+	var body io.ReadCloser
+	err := c.retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return permanent(err)
+		}
+		req.Header.Set("User-Agent", build.UserAgent)
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if err := checkStatus(res); err != nil {
+			res.Body.Close()
+			return err
+		}
+		body = res.Body
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("retrieving blob %s: %w", digestutil.Format(digest), err)
+	}
+	return body, nil
+}
@@ -0,0 +1,286 @@
+// Package client implements the storage node protocol: allocating space for
+// blobs, uploading them, accepting them and retrieving them.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	uclient "github.com/alanshaw/ucantone/client"
+	"github.com/alanshaw/ucantone/did"
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/volmedo/padron/pkg/build"
+	"github.com/volmedo/padron/pkg/server"
+)
+
+var log = logging.Logger("client")
+
+const (
+	// DefaultRetries is the number of times a failed request is retried.
+	DefaultRetries = 3
+	// DefaultRetryBackoff is the delay before the first retry, doubled on each
+	// subsequent retry.
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// ErrNotFound is returned when the node does not hold the requested blob.
+var ErrNotFound = errors.New("not found")
+
+// Failure is the error returned when the node executes an invocation and it
+// fails.
+type Failure struct {
+	// Name identifies the kind of failure, e.g. "HandlerNotFound".
+	Name    string
+	Message string
+}
+
+func (f *Failure) Error() string {
+	if f.Name == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s: %s", f.Name, f.Message)
+}
+
+// Client talks to a storage node on behalf of an agent.
+type Client struct {
+	id         principal.Signer
+	service    did.DID
+	serviceURL *url.URL
+	httpClient *http.Client
+	ucan       *uclient.HTTPClient
+	proofs     []ucan.Delegation
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for all requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.httpClient = c
+	}
+}
+
+// WithProofs sets the delegations that authorize the agent to invoke the node
+// on behalf of spaces. They are sent along with every invocation.
+func WithProofs(proofs ...ucan.Delegation) Option {
+	return func(cl *Client) {
+		cl.proofs = append(cl.proofs, proofs...)
+	}
+}
+
+// WithRetries sets how many times failed requests are retried, and the delay
+// before the first retry. Defaults to [DefaultRetries] and
+// [DefaultRetryBackoff].
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(cl *Client) {
+		cl.retries = retries
+		cl.backoff = backoff
+	}
+}
+
+// New creates a client for the node with the given DID, reachable at the given
+// URL, acting as the agent with the given identity.
+func New(id principal.Signer, service did.DID, serviceURL *url.URL, options ...Option) (*Client, error) {
+	c := &Client{
+		id:         id,
+		service:    service,
+		serviceURL: serviceURL,
+		httpClient: http.DefaultClient,
+		retries:    DefaultRetries,
+		backoff:    DefaultRetryBackoff,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	// UCAN responses are only decoded, so server errors must be caught by the
+	// transport to be retried
+	ucanHTTP := *c.httpClient
+	ucanHTTP.Transport = statusTransport{base: c.httpClient.Transport}
+	uc, err := uclient.NewHTTP(serviceURL, uclient.WithHTTPClient(&ucanHTTP))
+	if err != nil {
+		return nil, fmt.Errorf("creating UCAN client: %w", err)
+	}
+	c.ucan = uc
+	return c, nil
+}
+
+// ID returns the identity of the agent the client acts as.
+func (c *Client) ID() principal.Signer {
+	return c.id
+}
+
+// Info returns the information the node publishes about itself.
+func (c *Client) Info(ctx context.Context) (*server.ServerInfo, error) {
+	var info server.ServerInfo
+	err := c.retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.serviceURL.String(), nil)
+		if err != nil {
+			return permanent(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", build.UserAgent)
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if err := checkStatus(res); err != nil {
+			return err
+		}
+		if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+			return permanent(fmt.Errorf("decoding node info: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// invocationOptions are the options of every invocation sent to the node.
+func (c *Client) invocationOptions() []invocation.Option {
+	links := make([]ucan.Link, 0, len(c.proofs))
+	for _, p := range c.proofs {
+		links = append(links, p.Link())
+	}
+	return []invocation.Option{
+		invocation.WithAudience(c.service),
+		invocation.WithProofs(links...),
+	}
+}
+
+// execute sends the invocation to the node, along with the client proofs and
+// any additional invocations, and returns the successful result.
+func (c *Client) execute(ctx context.Context, inv ucan.Invocation, invocations ...ucan.Invocation) (ipld.Any, execution.Response, error) {
+	var res execution.Response
+	err := c.retry(ctx, func() error {
+		req := execution.NewRequest(
+			ctx,
+			inv,
+			execution.WithDelegations(c.proofs...),
+			execution.WithInvocations(invocations...),
+		)
+		var err error
+		res, err = c.ucan.Execute(req)
+		// only transport errors are worth retrying, the rest are encoding and
+		// decoding errors that would fail again
+		var uerr *url.Error
+		if err != nil && !errors.As(err, &uerr) {
+			return permanent(err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("executing %s: %w", inv.Command(), err)
+	}
+
+	o, x := result.Unwrap(res.Result())
+	if x != nil {
+		return nil, nil, toFailure(x)
+	}
+	return o, res, nil
+}
+
+func toFailure(x ipld.Any) error {
+	var model edm.ErrorModel
+	if err := datamodel.Rebind(datamodel.NewAny(x), &model); err != nil {
+		return &Failure{Message: fmt.Sprintf("%v", x)}
+	}
+	return &Failure{Name: model.ErrorName, Message: model.Message}
+}
+
+// permanentError wraps errors that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+func permanent(err error) error {
+	return permanentError{err}
+}
+
+// retry calls fn until it succeeds, returns a permanent error, the retries are
+// exhausted or the context is done. The delay between attempts doubles each
+// time.
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt >= c.retries {
+			return err
+		}
+
+		log.Warnw("request failed, retrying", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// checkStatus turns error responses into errors. Server errors may be retried,
+// client errors are permanent.
+func checkStatus(res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return permanent(ErrNotFound)
+	case res.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	case res.StatusCode >= http.StatusBadRequest:
+		return permanent(fmt.Errorf("unexpected status: %s", res.Status))
+	}
+	return nil
+}
+
+// statusTransport fails requests answered with server errors, so they are
+// retried like network errors.
+type statusTransport struct {
+	base http.RoundTripper
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= http.StatusInternalServerError {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return res, nil
+}
@@ -0,0 +1,201 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	blobcap "github.com/alanshaw/libracha/capabilities/blob"
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/client"
+	"github.com/volmedo/padron/pkg/server"
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/ucan"
+	blobucan "github.com/volmedo/padron/pkg/ucan/blob"
+	ucanserver "github.com/volmedo/padron/pkg/ucan/server"
+)
+
+func TestUploadAndRetrieve(t *testing.T) {
+	data := []byte("testing 1, 2, 3")
+	digest, err := mh.Sum(data, mh.SHA2_256, -1)
+	require.NoError(t, err)
+
+	var (
+		stored   []byte
+		failures = 1
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/blob/"+digestutil.Format(digest), r.URL.Path)
+		switch r.Method {
+		case http.MethodPut:
+			// fail the first upload, to exercise retries
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			require.Equal(t, "yes", r.Header.Get("X-Test"))
+			stored, _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(stored)
+		}
+	}))
+	defer ts.Close()
+
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	serviceURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	c, err := client.New(id, id.DID(), serviceURL, client.WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = c.Retrieve(context.Background(), digest)
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	err = c.Upload(context.Background(), &client.Address{
+		URL:     serviceURL.JoinPath("blob", digestutil.Format(digest)),
+		Headers: http.Header{"X-Test": []string{"yes"}},
+	}, bytes.NewReader(data), uint64(len(data)))
+	require.NoError(t, err)
+	require.Equal(t, data, stored)
+
+	body, err := c.Retrieve(context.Background(), digest)
+	require.NoError(t, err)
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestInvocations(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+	agent, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	blobs := blobstore.NewDsBlobstore(newDs())
+
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+	svc := blobsvc.NewService(service, publicURL, blobs, allocs, acceptances, commitments)
+
+	ucanSrv := ucanserver.NewHTTP(service)
+	for _, h := range []*ucan.Handler{blobucan.NewBlobAllocateHandler(svc), blobucan.NewBlobAcceptHandler(svc)} {
+		ucanSrv.Handle(h.Capability, h.Handler)
+	}
+	root := server.NewRootHandler(service, server.WithCommands(string(blobcap.Allocate.Command()), string(blobcap.Accept.Command())))
+
+	var (
+		requests int
+		failures int
+		garbage  bool
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case garbage:
+			w.Write([]byte("not a UCAN response"))
+		case r.Method == http.MethodGet:
+			root.ServeHTTP(w, r)
+		default:
+			ucanSrv.ServeHTTP(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	serviceURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	c, err := client.New(agent, service.DID(), serviceURL, client.WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+
+	data := []byte("testing 1, 2, 3")
+	digest, err := mh.Sum(data, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	blob := client.Blob{Digest: digest, Size: uint64(len(data))}
+
+	t.Run("info", func(t *testing.T) {
+		info, err := c.Info(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, service.DID().String(), info.ID)
+		require.Contains(t, info.UCAN.Commands, string(blobcap.Allocate.Command()))
+	})
+
+	t.Run("allocate", func(t *testing.T) {
+		cause, err := testutil.TestEchoCapability.Invoke(agent, agent, datamodel.Map{"message": "cause"})
+		require.NoError(t, err)
+
+		alloc, err := c.Allocate(t.Context(), agent, blob, cause)
+		require.NoError(t, err)
+		require.Equal(t, blob.Size, alloc.Size)
+		require.NotNil(t, alloc.Address)
+		require.Equal(t, svc.BlobURL(digest).String(), alloc.Address.URL.String())
+	})
+
+	t.Run("accept before upload fails", func(t *testing.T) {
+		_, err := c.Accept(t.Context(), agent, blob)
+		var failure *client.Failure
+		require.ErrorAs(t, err, &failure)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		require.NoError(t, blobs.Put(t.Context(), digest, blob.Size, bytes.NewReader(data)))
+
+		acc, err := c.Accept(t.Context(), agent, blob)
+		require.NoError(t, err)
+
+		commitment, err := commitments.Get(t.Context(), digest, agent.DID())
+		require.NoError(t, err)
+		require.Equal(t, commitment.Delegation.Link(), acc.Site)
+		require.NotNil(t, acc.LocationCommitment)
+		require.Equal(t, acc.Site, acc.LocationCommitment.Link())
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		requests, failures = 0, 1
+		_, err := c.Accept(t.Context(), agent, blob)
+		require.NoError(t, err)
+		require.Equal(t, 2, requests)
+	})
+
+	t.Run("does not retry undecodable responses", func(t *testing.T) {
+		requests, garbage = 0, true
+		defer func() { garbage = false }()
+		_, err := c.Accept(t.Context(), agent, blob)
+		require.Error(t, err)
+		require.Equal(t, 1, requests)
+	})
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type AdminConfig struct {
+	// Token is the bearer token of the admin endpoints. There is no flag for
+	// it, as command lines are visible to other local users: set it in the
+	// config file, the PADRON_ADMIN_TOKEN environment variable or TokenFile.
+	Token     string `mapstructure:"token" validate:"omitempty,min=16,excluded_with=TokenFile" toml:"token,omitempty"`
+	TokenFile string `mapstructure:"token_file" flag:"admin-token-file" toml:"token_file,omitempty"`
+}
+
+func (a AdminConfig) Validate() error {
+	return validateConfig(a)
+}
+
+func (a AdminConfig) ToAppConfig() (app.AdminConfig, error) {
+	token := a.Token
+	if a.TokenFile != "" {
+		data, err := os.ReadFile(a.TokenFile)
+		if err != nil {
+			return app.AdminConfig{}, fmt.Errorf("reading admin token file: %w", err)
+		}
+		token = strings.TrimRight(string(data), "\r\n")
+		if len(token) < 16 {
+			return app.AdminConfig{}, fmt.Errorf("admin token in %s must be at least 16 characters long", a.TokenFile)
+		}
+	}
+	return app.AdminConfig{
+		Token: token,
+	}, nil
+}
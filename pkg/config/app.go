@@ -7,9 +7,18 @@ import (
 )
 
 type Config struct {
-	Identity IdentityConfig `mapstructure:"identity" toml:"identity"`
-	Server   ServerConfig   `mapstructure:"server" toml:"server"`
-	Stores   StoreConfig    `mapstructure:"stores" toml:"stores"`
+	Identity  IdentityConfig  `mapstructure:"identity" toml:"identity"`
+	Server    ServerConfig    `mapstructure:"server" toml:"server"`
+	HTTP      HTTPConfig      `mapstructure:"http" toml:"http"`
+	Stores    StoreConfig     `mapstructure:"stores" toml:"stores"`
+	UCAN      UCANConfig      `mapstructure:"ucan" toml:"ucan"`
+	Blob      BlobConfig      `mapstructure:"blob" toml:"blob"`
+	Metrics   MetricsConfig   `mapstructure:"metrics" toml:"metrics"`
+	Tracing   TracingConfig   `mapstructure:"tracing" toml:"tracing"`
+	Health    HealthConfig    `mapstructure:"health" toml:"health"`
+	Logging   LoggingConfig   `mapstructure:"logging" toml:"logging"`
+	Admin     AdminConfig     `mapstructure:"admin" toml:"admin"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit" toml:"rate_limit"`
 }
 
 func (f Config) Validate() error {
@@ -35,10 +44,55 @@ func (f Config) ToAppConfig() (app.AppConfig, error) {
 		return app.AppConfig{}, fmt.Errorf("converting server config to app config: %s", err)
 	}
 
+	out.HTTP, err = f.HTTP.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting http config to app config: %s", err)
+	}
+
 	out.Stores, err = f.Stores.ToAppConfig()
 	if err != nil {
 		return app.AppConfig{}, fmt.Errorf("converting stores config to app config: %s", err)
 	}
 
+	out.UCAN, err = f.UCAN.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting ucan config to app config: %s", err)
+	}
+
+	out.Blob, err = f.Blob.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting blob config to app config: %s", err)
+	}
+
+	out.Metrics, err = f.Metrics.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting metrics config to app config: %s", err)
+	}
+
+	out.Tracing, err = f.Tracing.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting tracing config to app config: %s", err)
+	}
+
+	out.Health, err = f.Health.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting health config to app config: %s", err)
+	}
+
+	out.Logging, err = f.Logging.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting logging config to app config: %s", err)
+	}
+
+	out.Admin, err = f.Admin.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting admin config to app config: %s", err)
+	}
+
+	out.RateLimit, err = f.RateLimit.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting rate limit config to app config: %s", err)
+	}
+
 	return out, nil
 }
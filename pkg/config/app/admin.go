@@ -0,0 +1,8 @@
+package app
+
+// AdminConfig contains settings of the admin endpoints
+type AdminConfig struct {
+	// Token is the bearer token admin requests must present. If empty, the
+	// admin endpoints are disabled.
+	Token string
+}
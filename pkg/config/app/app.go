@@ -2,7 +2,16 @@ package app
 
 // AppConfig is the root configuration for the entire application
 type AppConfig struct {
-	Identity IdentityConfig
-	Server   ServerConfig
-	Stores   StoreConfig
+	Identity  IdentityConfig
+	Server    ServerConfig
+	HTTP      HTTPConfig
+	Stores    StoreConfig
+	UCAN      UCANConfig
+	Blob      BlobConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	Health    HealthConfig
+	Logging   LoggingConfig
+	Admin     AdminConfig
+	RateLimit RateLimitConfig
 }
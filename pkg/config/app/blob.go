@@ -0,0 +1,15 @@
+package app
+
+import "time"
+
+// BlobConfig contains blob service settings
+type BlobConfig struct {
+	// CommitmentTTL is the lifetime of the location commitments issued for
+	// accepted blobs.
+	CommitmentTTL time.Duration
+	// RenewInterval is how often expiring location commitments are renewed.
+	RenewInterval time.Duration
+	// RenewBefore is how long before their expiration location commitments are
+	// renewed.
+	RenewBefore time.Duration
+}
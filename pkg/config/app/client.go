@@ -0,0 +1,24 @@
+package app
+
+import (
+	"net/url"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// ClientConfig contains the settings of the client commands
+type ClientConfig struct {
+	// URL is the base URL of the node.
+	URL *url.URL
+	// Node is the DID of the node, the audience of invocations. It is the zero
+	// DID if not configured.
+	Node did.DID
+	// Signer is the identity of the agent invoking the node, or nil if not
+	// configured.
+	Signer principal.Signer
+	// Proofs are the delegations that authorize the agent to act on behalf of
+	// a space.
+	Proofs []ucan.Delegation
+}
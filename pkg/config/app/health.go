@@ -0,0 +1,14 @@
+package app
+
+import "time"
+
+// HealthConfig contains health check settings
+type HealthConfig struct {
+	// MinFreeSpace is the number of bytes that must be available in the data
+	// directory for the node to report itself as ready.
+	MinFreeSpace uint64
+	// DrainDelay is how long the node keeps serving requests after readiness
+	// starts failing on shutdown, giving orchestrators time to stop routing
+	// requests to it.
+	DrainDelay time.Duration
+}
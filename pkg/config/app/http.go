@@ -0,0 +1,25 @@
+package app
+
+import "time"
+
+// HTTPConfig contains the timeouts and limits of the HTTP server. A zero
+// timeout or limit disables it.
+type HTTPConfig struct {
+	// ReadHeaderTimeout is the time allowed to read request headers.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout is the time allowed to read an API request, including its
+	// body.
+	ReadTimeout time.Duration
+	// WriteTimeout is the time allowed to write an API response.
+	WriteTimeout time.Duration
+	// IdleTimeout is how long idle keep-alive connections are kept open.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum size of request headers.
+	MaxHeaderBytes int
+	// UCANBodyLimit is the maximum size of a UCAN invocation request body.
+	UCANBodyLimit int64
+	// UploadTimeout is the time allowed to complete a blob upload.
+	UploadTimeout time.Duration
+	// DownloadTimeout is the time allowed to write a blob download.
+	DownloadTimeout time.Duration
+}
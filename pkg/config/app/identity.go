@@ -1,10 +1,65 @@
 package app
 
 import (
+	"time"
+
 	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/signer"
 )
 
 // IdentityConfig contains identity-related configuration
 type IdentityConfig struct {
+	// Signer is the node identity. It is a did:web signer wrapping the key
+	// when the node has a did:web identity.
 	Signer principal.Signer
+	// RetiredKeys are keys replaced by the current one, still accepted as the
+	// audience of invocations until they expire.
+	RetiredKeys []RetiredKey
+}
+
+// RetiredKey is a key replaced by the current identity key.
+type RetiredKey struct {
+	Signer     principal.Signer
+	ValidUntil time.Time
+}
+
+// Key returns the key of the node, which is the signer itself unless the node
+// has a did:web identity.
+func (i IdentityConfig) Key() principal.Signer {
+	if w, ok := i.Signer.(signer.Unwrapper); ok {
+		return w.Unwrap()
+	}
+	return i.Signer
+}
+
+// ValidKeys returns the current key and the retired keys still valid at the
+// given time.
+func (i IdentityConfig) ValidKeys(now time.Time) []principal.Signer {
+	keys := []principal.Signer{i.Key()}
+	for _, rk := range i.RetiredKeys {
+		if now.Before(rk.ValidUntil) {
+			keys = append(keys, rk.Signer)
+		}
+	}
+	return keys
+}
+
+// Audience is a key accepted as the audience of invocations besides the node
+// identity, until ValidUntil, or forever if it is zero.
+type Audience struct {
+	Verifier   principal.Verifier
+	ValidUntil time.Time
+}
+
+// Audiences returns the keys accepted as the audience of invocations besides
+// the node identity: the key of a did:web node, and the retired keys.
+func (i IdentityConfig) Audiences() []Audience {
+	var audiences []Audience
+	if key := i.Key(); key.DID() != i.Signer.DID() {
+		audiences = append(audiences, Audience{Verifier: key.Verifier()})
+	}
+	for _, rk := range i.RetiredKeys {
+		audiences = append(audiences, Audience{Verifier: rk.Signer.Verifier(), ValidUntil: rk.ValidUntil})
+	}
+	return audiences
 }
@@ -0,0 +1,22 @@
+package app
+
+// LoggingConfig contains logging settings
+type LoggingConfig struct {
+	// Level is the level of all subsystems without a specific level.
+	Level string
+	// Subsystems are the levels of specific subsystems, e.g. "service/blob".
+	Subsystems map[string]string
+	// Format is either "console" or "json".
+	Format string
+	// File is the path logs are written to. If empty, logs are written to
+	// stderr.
+	File string
+	// MaxSize is the size in megabytes at which the log file is rotated.
+	MaxSize int
+	// MaxBackups is the number of rotated log files kept.
+	MaxBackups int
+	// MaxAge is the number of days rotated log files are kept.
+	MaxAge int
+	// Compress enables gzip compression of rotated log files.
+	Compress bool
+}
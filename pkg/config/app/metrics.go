@@ -0,0 +1,8 @@
+package app
+
+// MetricsConfig contains metrics settings
+type MetricsConfig struct {
+	// Addr is the address of a separate listener serving the metrics endpoint.
+	// If empty, metrics are served by the main HTTP server.
+	Addr string
+}
@@ -0,0 +1,17 @@
+package app
+
+// RateLimitConfig contains the request rate limits of the node. A zero rate
+// disables the limit.
+type RateLimitConfig struct {
+	// DIDRate is the number of UCAN invocations per second allowed for each
+	// issuer, in bursts of up to DIDBurst.
+	DIDRate  float64
+	DIDBurst int
+	// IPRate is the number of blob and invocation requests per second allowed for each
+	// client IP address, in bursts of up to IPBurst.
+	IPRate  float64
+	IPBurst int
+	// TrustForwarded takes client IP addresses from the X-Forwarded-For and
+	// X-Real-IP headers.
+	TrustForwarded bool
+}
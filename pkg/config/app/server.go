@@ -1,10 +1,42 @@
 package app
 
-import "net/url"
+import (
+	"crypto/x509"
+	"net/url"
+	"os"
+)
 
 // ServerConfig contains HTTP server settings
 type ServerConfig struct {
-	Host      string
-	Port      uint
+	Host string
+	Port uint
+	// Listen are the addresses the server listens on, in the forms accepted
+	// by listener.Listen.
+	Listen []string
+	// UnixSocketMode are the permissions of the Unix sockets in Listen. If
+	// zero, they are left to the umask.
+	UnixSocketMode os.FileMode
+	// PublicURL is the preferred public URL of the node, used for upload
+	// addresses.
 	PublicURL *url.URL
+	// MirrorURLs are additional public base URLs blobs can be retrieved from.
+	MirrorURLs []*url.URL
+	// TLS terminates TLS in the server, when enabled.
+	TLS TLSConfig
+	// H2C accepts HTTP/2 without TLS.
+	H2C bool
+}
+
+// TLSConfig contains the TLS settings of the server
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAs verify the client certificates required by the admin
+	// endpoints. If nil, no client certificate is required.
+	ClientCAs *x509.CertPool
+}
+
+// Enabled reports whether the server terminates TLS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
 }
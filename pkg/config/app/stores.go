@@ -10,6 +10,7 @@ type StoreConfig struct {
 	Blobs       BlobStoreConfig
 	Allocations AllocationStoreConfig
 	Acceptance  AcceptanceStoreConfig
+	Commitments CommitmentStoreConfig
 }
 
 // BlobStoreConfig contains blob-specific storage paths
@@ -27,3 +28,8 @@ type AllocationStoreConfig struct {
 type AcceptanceStoreConfig struct {
 	Dir string
 }
+
+// CommitmentStoreConfig contains location commitment-specific storage paths
+type CommitmentStoreConfig struct {
+	Dir string
+}
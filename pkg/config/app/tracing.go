@@ -0,0 +1,14 @@
+package app
+
+// TracingConfig contains OpenTelemetry tracing settings
+type TracingConfig struct {
+	// Exporter is the span exporter to use: "none", "stdout", "file" or "otlp".
+	Exporter string
+	// File is the path spans are written to by the "file" exporter.
+	File string
+	// Endpoint is the URL of the collector used by the "otlp" exporter. If
+	// empty, the standard OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint string
+	// SampleRatio is the fraction of traces sampled, between 0 and 1.
+	SampleRatio float64
+}
@@ -0,0 +1,13 @@
+package app
+
+import "time"
+
+// UCANConfig contains UCAN validation settings
+type UCANConfig struct {
+	// ClockSkew is the clock drift tolerated when checking the time bounds
+	// (nbf/exp) of invocations and delegations.
+	ClockSkew time.Duration
+	// Now returns the current time used for validation and expiry calculations.
+	// It is always time.Now when loaded from config, tests inject their own.
+	Now func() time.Time
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type BlobConfig struct {
+	CommitmentTTL time.Duration `mapstructure:"commitment_ttl" validate:"required" flag:"commitment-ttl" toml:"commitment_ttl"`
+	RenewInterval time.Duration `mapstructure:"renew_interval" validate:"required,ltfield=RenewBefore" flag:"commitment-renew-interval" toml:"renew_interval"`
+	RenewBefore   time.Duration `mapstructure:"renew_before" validate:"required,ltfield=CommitmentTTL" flag:"commitment-renew-before" toml:"renew_before"`
+}
+
+func (b BlobConfig) Validate() error {
+	return validateConfig(b)
+}
+
+func (b BlobConfig) ToAppConfig() (app.BlobConfig, error) {
+	return app.BlobConfig{
+		CommitmentTTL: b.CommitmentTTL,
+		RenewInterval: b.RenewInterval,
+		RenewBefore:   b.RenewBefore,
+	}, nil
+}
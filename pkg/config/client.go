@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/config/lib"
+)
+
+// ClientConfig configures the client commands. The node DID, agent key and
+// proofs are only needed by the commands that invoke the node.
+type ClientConfig struct {
+	URL     string   `mapstructure:"url" validate:"required,url" flag:"node-url" toml:"url"`
+	NodeDID string   `mapstructure:"node_did" validate:"omitempty,startswith=did:" flag:"node-did" toml:"node_did,omitempty"`
+	KeyFile string   `mapstructure:"key_file" flag:"agent-key-file" toml:"key_file,omitempty"`
+	Proofs  []string `mapstructure:"proofs" validate:"dive,required" flag:"proof" toml:"proofs,omitempty"`
+}
+
+func (c ClientConfig) Validate() error {
+	return validateConfig(c)
+}
+
+func (c ClientConfig) ToAppConfig() (app.ClientConfig, error) {
+	nodeURL, err := url.Parse(c.URL)
+	if err != nil {
+		return app.ClientConfig{}, fmt.Errorf("invalid node URL: %w", err)
+	}
+
+	var node did.DID
+	if c.NodeDID != "" {
+		node, err = did.Parse(c.NodeDID)
+		if err != nil {
+			return app.ClientConfig{}, fmt.Errorf("invalid node DID: %w", err)
+		}
+	}
+
+	var id principal.Signer
+	if c.KeyFile != "" {
+		id, err = lib.SignerFromKeyFile(c.KeyFile, nil)
+		if err != nil {
+			return app.ClientConfig{}, fmt.Errorf("loading agent key: %w", err)
+		}
+	}
+
+	proofs := make([]ucan.Delegation, 0, len(c.Proofs))
+	for _, p := range c.Proofs {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return app.ClientConfig{}, fmt.Errorf("reading delegation: %w", err)
+		}
+		dlg, err := delegation.Decode(data)
+		if err != nil {
+			return app.ClientConfig{}, fmt.Errorf("decoding delegation %s: %w", p, err)
+		}
+		proofs = append(proofs, dlg)
+	}
+
+	return app.ClientConfig{
+		URL:    nodeURL,
+		Node:   node,
+		Signer: id,
+		Proofs: proofs,
+	}, nil
+}
+
+// ClientRootConfig is the configuration of the client commands, which do not
+// need any of the node configuration.
+type ClientRootConfig struct {
+	Client ClientConfig `mapstructure:"client" toml:"client"`
+}
+
+func (c ClientRootConfig) Validate() error {
+	return validateConfig(c)
+}
@@ -12,7 +12,8 @@ type Normalizable interface {
 	Normalize()
 }
 
-func Load[T Validatable]() (T, error) {
+// Decode reads the config from viper without validating it.
+func Decode[T any]() (T, error) {
 	var out T
 	if err := viper.Unmarshal(&out); err != nil {
 		return out, err
@@ -20,6 +21,14 @@ func Load[T Validatable]() (T, error) {
 	if n, ok := any(&out).(Normalizable); ok {
 		n.Normalize()
 	}
+	return out, nil
+}
+
+func Load[T Validatable]() (T, error) {
+	out, err := Decode[T]()
+	if err != nil {
+		return out, err
+	}
 	if err := out.Validate(); err != nil {
 		return out, err
 	}
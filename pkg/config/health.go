@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type HealthConfig struct {
+	MinFreeSpace uint64        `mapstructure:"min_free_space" flag:"min-free-space" toml:"min_free_space"`
+	DrainDelay   time.Duration `mapstructure:"drain_delay" validate:"min=0" flag:"drain-delay" toml:"drain_delay"`
+}
+
+func (h HealthConfig) Validate() error {
+	return validateConfig(h)
+}
+
+func (h HealthConfig) ToAppConfig() (app.HealthConfig, error) {
+	return app.HealthConfig{
+		MinFreeSpace: h.MinFreeSpace,
+		DrainDelay:   h.DrainDelay,
+	}, nil
+}
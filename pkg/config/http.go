@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" validate:"min=0" flag:"http-read-header-timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" validate:"min=0" flag:"http-read-timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" validate:"min=0" flag:"http-write-timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" validate:"min=0" flag:"http-idle-timeout" toml:"idle_timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes" validate:"min=0" flag:"http-max-header-bytes" toml:"max_header_bytes"`
+	// UCANBodyLimit is the maximum size of the UCAN container of an
+	// invocation, in bytes.
+	UCANBodyLimit int64 `mapstructure:"ucan_body_limit" validate:"min=0" flag:"ucan-body-limit" toml:"ucan_body_limit"`
+	// UploadTimeout and DownloadTimeout replace the read and write timeouts for
+	// blob uploads and downloads, which take longer than API requests.
+	UploadTimeout   time.Duration `mapstructure:"upload_timeout" validate:"min=0" flag:"upload-timeout" toml:"upload_timeout"`
+	DownloadTimeout time.Duration `mapstructure:"download_timeout" validate:"min=0" flag:"download-timeout" toml:"download_timeout"`
+}
+
+func (h HTTPConfig) Validate() error {
+	return validateConfig(h)
+}
+
+func (h HTTPConfig) ToAppConfig() (app.HTTPConfig, error) {
+	return app.HTTPConfig{
+		ReadHeaderTimeout: h.ReadHeaderTimeout,
+		ReadTimeout:       h.ReadTimeout,
+		WriteTimeout:      h.WriteTimeout,
+		IdleTimeout:       h.IdleTimeout,
+		MaxHeaderBytes:    h.MaxHeaderBytes,
+		UCANBodyLimit:     h.UCANBodyLimit,
+		UploadTimeout:     h.UploadTimeout,
+		DownloadTimeout:   h.DownloadTimeout,
+	}, nil
+}
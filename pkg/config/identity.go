@@ -1,12 +1,62 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/signer"
+
 	"github.com/volmedo/padron/pkg/config/app"
 	"github.com/volmedo/padron/pkg/config/lib"
+	"github.com/volmedo/padron/pkg/keyagent"
 )
 
 type IdentityConfig struct {
-	KeyFile string `mapstructure:"key_file" validate:"required" flag:"key-file" toml:"key_file"`
+	// Signer selects where the private key is held: "key" (the default) loads
+	// it into the node process, "agent" leaves it in a key agent listening on
+	// AgentSocket.
+	Signer      string `mapstructure:"signer" validate:"omitempty,oneof=key agent" flag:"signer" toml:"signer,omitempty"`
+	AgentSocket string `mapstructure:"agent_socket" validate:"required_if=Signer agent" flag:"key-agent-socket" toml:"agent_socket,omitempty"`
+	// AgentSocketMode and AgentSocketGroup are the permissions and group of the
+	// socket created by the key agent, e.g. "0660" and the group of the node
+	// user when the node runs as a different user than the agent.
+	AgentSocketMode  string `mapstructure:"agent_socket_mode" flag:"socket-mode" toml:"agent_socket_mode,omitempty"`
+	AgentSocketGroup string `mapstructure:"agent_socket_group" flag:"socket-group" toml:"agent_socket_group,omitempty"`
+	// KeyFile is the path of the private key, as a PEM, JWK or multibase
+	// encoded ed25519 key.
+	KeyFile string `mapstructure:"key_file" validate:"required_without_all=Key AgentSocket,excluded_with=Key" flag:"key-file" toml:"key_file,omitempty"`
+	// Key is the private key itself, in any of the encodings accepted for the
+	// key file. It is meant to be set with the PADRON_IDENTITY_KEY environment
+	// variable in container deployments.
+	Key string `mapstructure:"key" toml:"key,omitempty"`
+	// Passphrase decrypts an encrypted PEM key. Prefer PassphraseFile, or the
+	// PADRON_IDENTITY_PASSPHRASE environment variable.
+	Passphrase     string `mapstructure:"passphrase" validate:"excluded_with=PassphraseFile" toml:"passphrase,omitempty"`
+	PassphraseFile string `mapstructure:"passphrase_file" flag:"key-passphrase-file" toml:"passphrase_file,omitempty"`
+	// DIDWeb is a did:web identity for the node, backed by the key. The node
+	// then serves its DID document at /.well-known/did.json and uses the
+	// did:web as issuer and audience instead of the did:key.
+	DIDWeb string `mapstructure:"did_web" validate:"omitempty,startswith=did:web:" flag:"did-web" toml:"did_web,omitempty"`
+	// RetiredKeys are keys replaced by the current one. Invocations addressed
+	// to them are still accepted until they expire.
+	RetiredKeys []RetiredKeyConfig `mapstructure:"retired_keys" validate:"dive" toml:"retired_keys,omitempty"`
+}
+
+// RetiredKeyConfig is a key replaced by the current identity key. Encrypted
+// keys are decrypted with the passphrase in PassphraseFile, or the passphrase
+// of the current key if it is not set.
+type RetiredKeyConfig struct {
+	KeyFile        string `mapstructure:"key_file" validate:"required" toml:"key_file"`
+	PassphraseFile string `mapstructure:"passphrase_file" toml:"passphrase_file,omitempty"`
+	// ValidUntil is an RFC 3339 timestamp, e.g. "2026-12-31T00:00:00Z".
+	ValidUntil string `mapstructure:"valid_until" validate:"required,datetime=2006-01-02T15:04:05Z07:00" toml:"valid_until"`
 }
 
 func (i IdentityConfig) Validate() error {
@@ -14,11 +64,168 @@ func (i IdentityConfig) Validate() error {
 }
 
 func (i IdentityConfig) ToAppConfig() (app.IdentityConfig, error) {
-	id, err := lib.SignerFromEd25519PEMFile(i.KeyFile)
+	id, err := i.LoadSigner()
+	if err != nil {
+		return app.IdentityConfig{}, err
+	}
+	if i.DIDWeb != "" {
+		id, err = wrapDIDWeb(id, i.DIDWeb)
+		if err != nil {
+			return app.IdentityConfig{}, err
+		}
+	}
+
+	passphrase, err := i.LoadPassphrase()
 	if err != nil {
 		return app.IdentityConfig{}, err
 	}
+	retired := make([]app.RetiredKey, 0, len(i.RetiredKeys))
+	for _, rk := range i.RetiredKeys {
+		passphrase := passphrase
+		if rk.PassphraseFile != "" {
+			passphrase, err = readPassphrase(rk.PassphraseFile)
+			if err != nil {
+				return app.IdentityConfig{}, fmt.Errorf("loading retired key %s: %w", rk.KeyFile, err)
+			}
+		}
+		key, err := lib.SignerFromKeyFile(rk.KeyFile, passphrase)
+		if err != nil {
+			return app.IdentityConfig{}, fmt.Errorf("loading retired key %s: %w", rk.KeyFile, err)
+		}
+		until, err := time.Parse(time.RFC3339, rk.ValidUntil)
+		if err != nil {
+			return app.IdentityConfig{}, fmt.Errorf("invalid valid_until of retired key %s: %w", rk.KeyFile, err)
+		}
+		retired = append(retired, app.RetiredKey{Signer: key, ValidUntil: until})
+	}
+
 	return app.IdentityConfig{
-		Signer: id,
+		Signer:      id,
+		RetiredKeys: retired,
 	}, nil
 }
+
+// AgentListenOptions are the options of the key agent socket.
+func (i IdentityConfig) AgentListenOptions() ([]keyagent.ListenOption, error) {
+	var options []keyagent.ListenOption
+	if i.AgentSocketMode != "" {
+		mode, err := parseFileMode("identity.agent_socket_mode", i.AgentSocketMode)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, keyagent.WithMode(mode))
+	}
+	if i.AgentSocketGroup != "" {
+		gid, err := lookupGroup(i.AgentSocketGroup)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity.agent_socket_group: %w", err)
+		}
+		options = append(options, keyagent.WithGroup(gid))
+	}
+	return options, nil
+}
+
+// lookupGroup returns the ID of a group given by name or ID.
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// LoadSigner connects to the key agent, or loads the private key from the key
+// file or the inline key.
+func (i IdentityConfig) LoadSigner() (principal.Signer, error) {
+	if i.Signer == "agent" {
+		id, err := keyagent.Dial(i.AgentSocket)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to key agent at %s: %w", i.AgentSocket, err)
+		}
+		return id, nil
+	}
+	if i.Key == "" && i.KeyFile == "" {
+		return nil, errors.New("identity.key_file or identity.key is required unless identity.signer is agent")
+	}
+
+	passphrase, err := i.LoadPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		id     principal.Signer
+		source string
+	)
+	if i.Key != "" {
+		source = "identity.key"
+		id, err = lib.ParseSigner([]byte(i.Key), passphrase)
+	} else {
+		source = "identity.key_file " + i.KeyFile
+		id, err = lib.SignerFromKeyFile(i.KeyFile, passphrase)
+	}
+	if errors.Is(err, lib.ErrPassphraseRequired) {
+		return nil, fmt.Errorf("loading %s: %w, set identity.passphrase_file or PADRON_IDENTITY_PASSPHRASE", source, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", source, err)
+	}
+	return id, nil
+}
+
+// LoadPassphrase returns the passphrase of encrypted keys, read from
+// PassphraseFile if it is set.
+func (i IdentityConfig) LoadPassphrase() ([]byte, error) {
+	if i.PassphraseFile == "" {
+		return []byte(i.Passphrase), nil
+	}
+	return readPassphrase(i.PassphraseFile)
+}
+
+func readPassphrase(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase file: %w", err)
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// wrapDIDWeb gives the signer the did:web identity. Only did:web identities
+// without a path are supported, as their DID document is served at the well
+// known location.
+func wrapDIDWeb(id principal.Signer, didWeb string) (principal.Signer, error) {
+	d, err := did.Parse(didWeb)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity.did_web: %w", err)
+	}
+	if strings.Contains(strings.TrimPrefix(d.String(), "did:web:"), ":") {
+		return nil, fmt.Errorf("invalid identity.did_web %s: did:web identities with a path are not supported", d)
+	}
+	wrapped, err := signer.Wrap(id, d)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping key in identity.did_web: %w", err)
+	}
+	return wrapped, nil
+}
+
+// IdentityRootConfig is the part of the configuration needed by the commands
+// that manage the identity key.
+type IdentityRootConfig struct {
+	Identity IdentityConfig `mapstructure:"identity" toml:"identity"`
+}
+
+func (i IdentityRootConfig) Validate() error {
+	return validateConfig(i)
+}
+
+// parseFileMode parses octal permissions like 0660, set at key.
+func parseFileMode(key, value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid %s %q, expected octal permissions like 0660", key, value)
+	}
+	return os.FileMode(mode), nil
+}
@@ -4,9 +4,11 @@ import (
 	crypto_ed25519 "crypto/ed25519"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/alanshaw/ucantone/principal"
 	"github.com/alanshaw/ucantone/principal/ed25519"
@@ -22,7 +24,12 @@ func SignerFromEd25519PEMFile(path string) (principal.Signer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading private key: %w", err)
 	}
+	return SignerFromEd25519PEM(pemData)
+}
 
+// SignerFromEd25519PEM parses the first PKCS#8 "PRIVATE KEY" block of the PEM
+// data, which must hold an ed25519 key.
+func SignerFromEd25519PEM(pemData []byte) (principal.Signer, error) {
 	var privateKey *crypto_ed25519.PrivateKey
 	rest := pemData
 
@@ -57,3 +64,51 @@ func SignerFromEd25519PEMFile(path string) (principal.Signer, error) {
 	}
 	return ed25519.FromRaw(privateKey.Seed())
 }
+
+// EncodeEd25519PEM encodes the signer private key as a PKCS#8 PEM block, the
+// format read by [SignerFromEd25519PEMFile].
+func EncodeEd25519PEM(signer principal.Signer) ([]byte, error) {
+	if signer.Code() != ed25519.Code {
+		return nil, fmt.Errorf("not an ed25519 signer: 0x%x", signer.Code())
+	}
+	if signer.Raw() == nil {
+		return nil, errors.New("the private key of the signer is not available")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(crypto_ed25519.NewKeyFromSeed(signer.Raw()))
+	if err != nil {
+		return nil, fmt.Errorf("encoding private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteEd25519PEMFile writes the signer private key to a PEM file readable
+// only by its owner. Missing parent directories are created, also private to
+// the owner. It fails if the file exists, unless overwrite is set.
+func WriteEd25519PEMFile(path string, signer principal.Signer, overwrite bool) error {
+	data, err := EncodeEd25519PEM(signer)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating key directory: %w", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return err
+	}
+	// the file may have existed with looser permissions
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return fmt.Errorf("setting key file permissions: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing private key: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,138 @@
+package lib
+
+import (
+	"bytes"
+	crypto_ed25519 "crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/youmark/pkcs8"
+)
+
+// ErrPassphraseRequired is returned when parsing an encrypted key without a
+// passphrase.
+var ErrPassphraseRequired = errors.New("the key is encrypted and no passphrase was provided")
+
+// SignerFromKeyFile reads an ed25519 private key from a file in any of the
+// encodings accepted by [ParseSigner].
+func SignerFromKeyFile(path string, passphrase []byte) (principal.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	return ParseSigner(data, passphrase)
+}
+
+// ParseSigner parses an ed25519 private key, detecting its encoding:
+//   - a PEM file with a PKCS#8 "PRIVATE KEY" block, or an "ENCRYPTED PRIVATE
+//     KEY" block decrypted with the passphrase
+//   - a JWK of type OKP and curve Ed25519
+//   - a multibase encoded private key, like other Storacha tools emit
+func ParseSigner(data []byte, passphrase []byte) (principal.Signer, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil, errors.New("the key is empty")
+	case bytes.HasPrefix(data, []byte("-----BEGIN")):
+		return signerFromPEM(data, passphrase)
+	case data[0] == '{':
+		return signerFromJWK(data)
+	default:
+		id, err := ed25519.Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("unrecognized key, expected a PEM, JWK or multibase encoded ed25519 private key: %w", err)
+		}
+		return id, nil
+	}
+}
+
+func signerFromPEM(data []byte, passphrase []byte) (principal.Signer, error) {
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("could not find a PRIVATE KEY or ENCRYPTED PRIVATE KEY block in the PEM data")
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			return SignerFromEd25519PEM(pem.EncodeToMemory(block))
+		case "ENCRYPTED PRIVATE KEY":
+			if len(passphrase) == 0 {
+				return nil, ErrPassphraseRequired
+			}
+			key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("decrypting PKCS#8 private key: %w", err)
+			}
+			sk, ok := key.(crypto_ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("the decrypted key is a %T, not an ED25519 private key", key)
+			}
+			return ed25519.FromRaw(sk.Seed())
+		}
+	}
+}
+
+// jwk holds the members of a JSON Web Key relevant to ed25519 keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	D   string `json:"d"`
+	X   string `json:"x"`
+}
+
+func signerFromJWK(data []byte) (principal.Signer, error) {
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("parsing JWK: %w", err)
+	}
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported JWK, expected kty OKP and crv Ed25519, got kty %q and crv %q", k.Kty, k.Crv)
+	}
+	if k.D == "" {
+		return nil, errors.New("the JWK is a public key, it has no private key (d) member")
+	}
+
+	seed, err := base64.RawURLEncoding.DecodeString(k.D)
+	if err != nil {
+		return nil, fmt.Errorf("decoding JWK private key: %w", err)
+	}
+	id, err := ed25519.FromRaw(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK private key: %w", err)
+	}
+
+	if k.X != "" {
+		pub, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding JWK public key: %w", err)
+		}
+		if !bytes.Equal(pub, id.Verifier().Raw()) {
+			return nil, errors.New("the JWK public key (x) does not match its private key (d)")
+		}
+	}
+	return id, nil
+}
+
+// EncodeEd25519JWK encodes the signer private key as a JWK.
+func EncodeEd25519JWK(signer principal.Signer) ([]byte, error) {
+	if signer.Code() != ed25519.Code {
+		return nil, fmt.Errorf("not an ed25519 signer: 0x%x", signer.Code())
+	}
+	if signer.Raw() == nil {
+		return nil, errors.New("the private key of the signer is not available")
+	}
+	return json.Marshal(jwk{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		D:   base64.RawURLEncoding.EncodeToString(signer.Raw()),
+		X:   base64.RawURLEncoding.EncodeToString(signer.Verifier().Raw()),
+	})
+}
@@ -0,0 +1,62 @@
+package lib_test
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"encoding/pem"
+	"testing"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/principal/signer"
+	"github.com/stretchr/testify/require"
+	"github.com/youmark/pkcs8"
+
+	"github.com/volmedo/padron/pkg/config/lib"
+)
+
+func TestParseSigner(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	passphrase := []byte("correct horse battery staple")
+
+	plain, err := lib.EncodeEd25519PEM(id)
+	require.NoError(t, err)
+	der, err := pkcs8.MarshalPrivateKey(crypto_ed25519.NewKeyFromSeed(id.Raw()), passphrase, nil)
+	require.NoError(t, err)
+	encrypted := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+	jwk, err := lib.EncodeEd25519JWK(id)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		data       []byte
+		passphrase []byte
+	}{
+		{"PEM", plain, nil},
+		{"encrypted PEM", encrypted, passphrase},
+		{"JWK", jwk, nil},
+		{"multibase", []byte(signer.Format(id) + "\n"), nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := lib.ParseSigner(tc.data, tc.passphrase)
+			require.NoError(t, err)
+			require.Equal(t, id.DID(), parsed.DID())
+		})
+	}
+
+	t.Run("encrypted PEM without passphrase", func(t *testing.T) {
+		_, err := lib.ParseSigner(encrypted, nil)
+		require.ErrorIs(t, err, lib.ErrPassphraseRequired)
+	})
+
+	t.Run("encrypted PEM with wrong passphrase", func(t *testing.T) {
+		_, err := lib.ParseSigner(encrypted, []byte("wrong"))
+		require.Error(t, err)
+	})
+
+	for _, data := range []string{"", "   \n", "not a key", `{"kty":"RSA"}`, "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"} {
+		t.Run("rejects "+data, func(t *testing.T) {
+			_, err := lib.ParseSigner([]byte(data), nil)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"maps"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type LoggingConfig struct {
+	Level      string            `mapstructure:"level" validate:"required,oneof=debug info warn error dpanic panic fatal" flag:"log-level" toml:"level"`
+	Subsystems map[string]string `mapstructure:"subsystems" validate:"dive,keys,required,endkeys,oneof=debug info warn error dpanic panic fatal" flag:"log-subsystem-level" toml:"subsystems,omitempty"`
+	Format     string            `mapstructure:"format" validate:"omitempty,oneof=console json" flag:"log-format" toml:"format"`
+	File       string            `mapstructure:"file" flag:"log-file" toml:"file,omitempty"`
+	MaxSize    int               `mapstructure:"max_size" validate:"min=0" flag:"log-max-size" toml:"max_size"`
+	MaxBackups int               `mapstructure:"max_backups" validate:"min=0" flag:"log-max-backups" toml:"max_backups"`
+	MaxAge     int               `mapstructure:"max_age" validate:"min=0" flag:"log-max-age" toml:"max_age"`
+	Compress   bool              `mapstructure:"compress" flag:"log-compress" toml:"compress"`
+}
+
+func (l LoggingConfig) Validate() error {
+	return validateConfig(l)
+}
+
+func (l LoggingConfig) ToAppConfig() (app.LoggingConfig, error) {
+	format := l.Format
+	if format == "" {
+		format = "console"
+	}
+	return app.LoggingConfig{
+		Level:      l.Level,
+		Subsystems: maps.Clone(l.Subsystems),
+		Format:     format,
+		File:       l.File,
+		MaxSize:    l.MaxSize,
+		MaxBackups: l.MaxBackups,
+		MaxAge:     l.MaxAge,
+		Compress:   l.Compress,
+	}, nil
+}
+
+// LoggingRootConfig is the part of the configuration needed to set up logging,
+// which happens before the configuration of a command is loaded.
+type LoggingRootConfig struct {
+	Logging LoggingConfig `mapstructure:"logging" toml:"logging"`
+}
+
+func (l LoggingRootConfig) Validate() error {
+	return validateConfig(l)
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type MetricsConfig struct {
+	Addr string `mapstructure:"addr" validate:"omitempty,hostname_port" flag:"metrics-addr" toml:"addr,omitempty"`
+}
+
+func (m MetricsConfig) Validate() error {
+	return validateConfig(m)
+}
+
+func (m MetricsConfig) ToAppConfig() (app.MetricsConfig, error) {
+	return app.MetricsConfig{
+		Addr: m.Addr,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type RateLimitConfig struct {
+	DIDRate  float64 `mapstructure:"did_rate" validate:"min=0" flag:"rate-limit-did" toml:"did_rate"`
+	DIDBurst int     `mapstructure:"did_burst" validate:"min=0" flag:"rate-limit-did-burst" toml:"did_burst"`
+	IPRate   float64 `mapstructure:"ip_rate" validate:"min=0" flag:"rate-limit-ip" toml:"ip_rate"`
+	IPBurst  int     `mapstructure:"ip_burst" validate:"min=0" flag:"rate-limit-ip-burst" toml:"ip_burst"`
+	// TrustForwarded keys IP limits by the client address in the
+	// X-Forwarded-For or X-Real-IP headers. Only enable it behind a reverse
+	// proxy that sets them.
+	TrustForwarded bool `mapstructure:"trust_forwarded" flag:"rate-limit-trust-forwarded" toml:"trust_forwarded"`
+}
+
+func (r RateLimitConfig) Validate() error {
+	return validateConfig(r)
+}
+
+func (r RateLimitConfig) ToAppConfig() (app.RateLimitConfig, error) {
+	return app.RateLimitConfig{
+		DIDRate:        r.DIDRate,
+		DIDBurst:       r.DIDBurst,
+		IPRate:         r.IPRate,
+		IPBurst:        r.IPBurst,
+		TrustForwarded: r.TrustForwarded,
+	}, nil
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"strconv"
 
 	"github.com/volmedo/padron/pkg/config/app"
 )
@@ -11,6 +15,31 @@ type ServerConfig struct {
 	Port      uint   `mapstructure:"port" validate:"required,min=1,max=65535" flag:"port" toml:"port"`
 	Host      string `mapstructure:"host" validate:"required" flag:"host" toml:"host"`
 	PublicURL string `mapstructure:"public_url" flag:"public-url" toml:"public_url"`
+	// Listen are the addresses the server listens on: TCP host:port
+	// addresses, Unix sockets as unix:/path/to/socket, and sockets passed by
+	// systemd as systemd or systemd:name. Defaults to Host and Port.
+	Listen []string `mapstructure:"listen" validate:"dive,required" flag:"listen" toml:"listen,omitempty"`
+	// UnixSocketMode are the permissions of the Unix sockets in Listen, in
+	// octal, e.g. "0660" to let a reverse proxy in the group of the node
+	// connect. Defaults to the permissions allowed by the umask.
+	UnixSocketMode string `mapstructure:"unix_socket_mode" flag:"unix-socket-mode" toml:"unix_socket_mode,omitempty"`
+	// MirrorURLs are additional public base URLs blobs can be retrieved from,
+	// e.g. a CDN in front of the node.
+	MirrorURLs []string `mapstructure:"mirror_urls" validate:"dive,url" flag:"mirror-url" toml:"mirror_urls,omitempty"`
+	// TLS makes the server terminate TLS itself, for nodes exposed without a
+	// reverse proxy.
+	TLS TLSConfig `mapstructure:"tls" toml:"tls"`
+	// H2C accepts HTTP/2 without TLS, e.g. from a reverse proxy.
+	H2C bool `mapstructure:"h2c" flag:"h2c" toml:"h2c"`
+}
+
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files, loaded again when they change.
+	CertFile string `mapstructure:"cert_file" validate:"required_with=KeyFile" flag:"tls-cert-file" toml:"cert_file,omitempty"`
+	KeyFile  string `mapstructure:"key_file" validate:"required_with=CertFile" flag:"tls-key-file" toml:"key_file,omitempty"`
+	// ClientCAFile is a PEM file of the CAs signing the client certificates
+	// required by the admin endpoints.
+	ClientCAFile string `mapstructure:"client_ca_file" validate:"excluded_without=CertFile" flag:"tls-client-ca-file" toml:"client_ca_file,omitempty"`
 }
 
 func (s ServerConfig) Validate() error {
@@ -23,9 +52,58 @@ func (s ServerConfig) ToAppConfig() (app.ServerConfig, error) {
 		return app.ServerConfig{}, fmt.Errorf("invalid public URL: %w", err)
 	}
 
+	mirrorURLs := make([]*url.URL, 0, len(s.MirrorURLs))
+	for _, u := range s.MirrorURLs {
+		mirrorURL, err := url.Parse(u)
+		if err != nil {
+			return app.ServerConfig{}, fmt.Errorf("invalid mirror URL %q: %w", u, err)
+		}
+		mirrorURLs = append(mirrorURLs, mirrorURL)
+	}
+
+	tlsCfg, err := s.TLS.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	var socketMode os.FileMode
+	if s.UnixSocketMode != "" {
+		if socketMode, err = parseFileMode("server.unix_socket_mode", s.UnixSocketMode); err != nil {
+			return app.ServerConfig{}, err
+		}
+	}
+
+	listen := s.Listen
+	if len(listen) == 0 {
+		listen = []string{net.JoinHostPort(s.Host, strconv.FormatUint(uint64(s.Port), 10))}
+	}
+
 	return app.ServerConfig{
-		Host:      s.Host,
-		Port:      s.Port,
-		PublicURL: publicURL,
+		Host:           s.Host,
+		Port:           s.Port,
+		Listen:         listen,
+		UnixSocketMode: socketMode,
+		PublicURL:      publicURL,
+		MirrorURLs:     mirrorURLs,
+		TLS:            tlsCfg,
+		H2C:            s.H2C,
 	}, nil
 }
+
+func (t TLSConfig) ToAppConfig() (app.TLSConfig, error) {
+	out := app.TLSConfig{
+		CertFile: t.CertFile,
+		KeyFile:  t.KeyFile,
+	}
+	if t.ClientCAFile != "" {
+		data, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return app.TLSConfig{}, fmt.Errorf("reading TLS client CA file: %w", err)
+		}
+		out.ClientCAs = x509.NewCertPool()
+		if !out.ClientCAs.AppendCertsFromPEM(data) {
+			return app.TLSConfig{}, fmt.Errorf("no certificates found in TLS client CA file %s", t.ClientCAFile)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// DefaultFilePath is the config file loaded when none is given, in the user
+// config directory, e.g. ~/.config/padron/config.toml.
+func DefaultFilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "padron", "config.toml"), nil
+}
+
+// BindEnv binds every key of the given config structs to its PADRON_*
+// environment variable, so keys without a flag can be set from the
+// environment as well.
+func BindEnv(cfgs ...any) error {
+	var err error
+	for _, cfg := range cfgs {
+		walk(reflect.ValueOf(cfg), "", func(key string, _ reflect.StructField, _ reflect.Value) {
+			if err == nil {
+				err = viper.BindEnv(key)
+			}
+		})
+	}
+	return err
+}
+
+// Setting is a single config key with its effective value and where that
+// value came from.
+type Setting struct {
+	Key    string
+	Value  any
+	Source string
+	Usage  string
+}
+
+// usages describe the keys that cannot be set with a flag, or only with a
+// flag of a command other than serve.
+var usages = map[string]string{
+	"identity.agent_socket_mode":  "Permissions of the key agent socket, in octal, e.g. '0660'. Set with --socket-mode of the key agent command",
+	"identity.agent_socket_group": "Group owning the key agent socket, by name or ID. Set with --socket-group of the key agent command",
+	"identity.key":                "Private key itself, in any encoding accepted for key_file. Prefer the PADRON_IDENTITY_KEY environment variable",
+	"identity.passphrase":         "Passphrase of an encrypted PEM key. Prefer passphrase_file or the PADRON_IDENTITY_PASSPHRASE environment variable",
+	"identity.retired_keys":       "Keys replaced by the current one, still accepted until they expire, e.g. [{key_file = 'old.pem', valid_until = '2026-12-31T00:00:00Z'}], with an optional passphrase_file for encrypted keys",
+	"admin.token":                 "Bearer token required by the admin endpoints, which are disabled if empty. Prefer token_file or the PADRON_ADMIN_TOKEN environment variable",
+}
+
+// Settings lists the keys of cfg, a config struct decoded by viper, with their
+// effective value and source. Flags are looked up in flags to tell whether
+// they were set on the command line. Secret values are redacted.
+func Settings(cfg any, flags *pflag.FlagSet) []Setting {
+	var settings []Setting
+	walk(reflect.ValueOf(cfg), "", func(key string, field reflect.StructField, value reflect.Value) {
+		s := Setting{
+			Key:    key,
+			Value:  plain(value),
+			Source: settingSource(key, field.Tag.Get("flag"), flags),
+			Usage:  usage(key, field.Tag.Get("flag"), flags),
+		}
+		if isSecret(key) && !value.IsZero() {
+			s.Value = redacted
+		}
+		settings = append(settings, s)
+	})
+	return settings
+}
+
+// Defaults returns the config built from the default values of flags alone,
+// ignoring environment variables and config files.
+func Defaults(flags *pflag.FlagSet) (Config, error) {
+	v := viper.New()
+	var bindErr error
+	walk(reflect.ValueOf(Config{}), "", func(key string, field reflect.StructField, _ reflect.Value) {
+		if name := field.Tag.Get("flag"); name != "" && bindErr == nil {
+			if f := flags.Lookup(name); f != nil {
+				bindErr = v.BindPFlag(key, f)
+			}
+		}
+	})
+	if bindErr != nil {
+		return Config{}, bindErr
+	}
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// DefaultTOML renders the default config as a commented TOML file. Keys
+// without a default value are commented out.
+func DefaultTOML(flags *pflag.FlagSet) ([]byte, error) {
+	cfg, err := Defaults(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("# padrón configuration file.\n")
+	b.WriteString("#\n")
+	b.WriteString("# Every key can also be set with a command line flag, or with a PADRON_*\n")
+	b.WriteString("# environment variable, e.g. PADRON_SERVER_PORT for server.port.\n")
+
+	section := ""
+	for _, s := range Settings(cfg, flags) {
+		table, name, _ := strings.Cut(s.Key, ".")
+		if table != section {
+			fmt.Fprintf(&b, "\n[%s]\n", table)
+			section = table
+		}
+		if s.Usage != "" {
+			fmt.Fprintf(&b, "# %s\n", s.Usage)
+		}
+		value, err := FormatValue(s.Value)
+		if err != nil {
+			return nil, fmt.Errorf("formatting %s: %w", s.Key, err)
+		}
+		line := name + " = " + value
+		if reflect.ValueOf(s.Value).IsZero() {
+			line = "# " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.Bytes(), nil
+}
+
+// FormatValue formats a config value as a TOML value, with tables inline.
+func FormatValue(value any) (string, error) {
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).SetTablesInline(true).Encode(map[string]any{"v": value}); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(b.String(), "v = "), "\n"), nil
+}
+
+// walk calls fn for every leaf field of a config struct, keyed by the dotted
+// path of mapstructure names.
+func walk(v reflect.Value, prefix string, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeFor[time.Time]() {
+			walk(v.Field(i), key+".", fn)
+			continue
+		}
+		fn(key, field, v.Field(i))
+	}
+}
+
+// plain returns the value of a field in a form that renders well as TOML.
+func plain(v reflect.Value) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// settingSource reports where the value of a key came from, following viper
+// precedence: flag, environment variable, config file, then default.
+func settingSource(key, flag string, flags *pflag.FlagSet) string {
+	if flag != "" && flags != nil {
+		if f := flags.Lookup(flag); f != nil && f.Changed {
+			return "flag --" + flag
+		}
+	}
+	if env := envVarForKey(key); env != "" {
+		if _, ok := os.LookupEnv(env); ok {
+			return "env " + env
+		}
+	}
+	if cfg := viper.ConfigFileUsed(); cfg != "" && viper.InConfig(key) {
+		return "config " + cfg
+	}
+	return "default"
+}
+
+func usage(key, flag string, flags *pflag.FlagSet) string {
+	if flag != "" && flags != nil {
+		if f := flags.Lookup(flag); f != nil {
+			return f.Usage
+		}
+	}
+	return usages[key]
+}
+
+// Diff returns the keys whose values differ between two configs.
+func Diff(a, b Config) []string {
+	others := map[string]reflect.Value{}
+	walk(reflect.ValueOf(b), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		others[key] = value
+	})
+
+	var keys []string
+	walk(reflect.ValueOf(a), "", func(key string, _ reflect.StructField, value reflect.Value) {
+		if !reflect.DeepEqual(value.Interface(), others[key].Interface()) {
+			keys = append(keys, key)
+		}
+	})
+	return keys
+}
@@ -0,0 +1,172 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/config"
+)
+
+// testFlags are some of the serve flags, with their defaults.
+func testFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Uint("port", 3000, "Port to bind the server to")
+	fs.String("host", "localhost", "Host to bind the server to")
+	fs.Duration("commitment-ttl", 30*24*time.Hour, "Lifetime of location commitments")
+	fs.String("log-level", "warn", "Log level of all subsystems")
+	fs.StringToString("log-subsystem-level", map[string]string{"cmd/serve": "info"}, "Log level of specific subsystems")
+	fs.String("admin-token-file", "", "File holding the bearer token of the admin endpoints")
+	return fs
+}
+
+func TestDefaultTOML(t *testing.T) {
+	flags := testFlags()
+	data, err := config.DefaultTOML(flags)
+	require.NoError(t, err)
+
+	t.Run("documents keys with their flag usage", func(t *testing.T) {
+		require.Contains(t, string(data), "[server]\n# Port to bind the server to\nport = 3000\n")
+	})
+
+	t.Run("comments out keys without a default", func(t *testing.T) {
+		require.Contains(t, string(data), "# token = ''\n")
+	})
+
+	t.Run("round trips the defaults", func(t *testing.T) {
+		v := viper.New()
+		v.SetConfigType("toml")
+		require.NoError(t, v.ReadConfig(bytes.NewReader(data)))
+		var got config.Config
+		require.NoError(t, v.Unmarshal(&got))
+
+		want, err := config.Defaults(flags)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+		require.Equal(t, uint(3000), got.Server.Port)
+		require.Equal(t, 30*24*time.Hour, got.Blob.CommitmentTTL)
+		require.Equal(t, map[string]string{"cmd/serve": "info"}, got.Logging.Subsystems)
+	})
+}
+
+func TestSettings(t *testing.T) {
+	settings := func(cfg config.Config) map[string]config.Setting {
+		out := map[string]config.Setting{}
+		for _, s := range config.Settings(cfg, testFlags()) {
+			out[s.Key] = s
+		}
+		return out
+	}
+
+	t.Run("redacts secrets", func(t *testing.T) {
+		var cfg config.Config
+		cfg.Identity.Key = "not-a-real-key"
+		cfg.Identity.Passphrase = "correct horse battery staple"
+		cfg.Admin.Token = "0123456789abcdef"
+		cfg.Server.Host = "localhost"
+
+		s := settings(cfg)
+		for _, key := range []string{"identity.key", "identity.passphrase", "admin.token"} {
+			require.Equal(t, "<redacted>", s[key].Value, key)
+		}
+		require.Equal(t, "localhost", s["server.host"].Value)
+	})
+
+	t.Run("leaves unset secrets empty", func(t *testing.T) {
+		require.Equal(t, "", settings(config.Config{})["admin.token"].Value)
+	})
+
+	t.Run("reports flags set on the command line", func(t *testing.T) {
+		flags := testFlags()
+		require.NoError(t, flags.Set("port", "4000"))
+		for _, s := range config.Settings(config.Config{}, flags) {
+			if s.Key == "server.port" {
+				require.Equal(t, "flag --port", s.Source)
+				require.Equal(t, "Port to bind the server to", s.Usage)
+				return
+			}
+		}
+		t.Fatal("server.port not listed")
+	})
+}
+
+func TestBindEnv(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Reset()
+	viper.AutomaticEnv()
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetEnvPrefix("PADRON")
+
+	t.Setenv("PADRON_IDENTITY_KEY", "not-a-real-key")
+	t.Setenv("PADRON_SERVER_PORT", "4000")
+	t.Setenv("PADRON_CLIENT_URL", "https://node.example.com")
+
+	// keys are only decoded from the environment once bound
+	cfg, err := config.Decode[config.Config]()
+	require.NoError(t, err)
+	require.Empty(t, cfg.Identity.Key)
+
+	require.NoError(t, config.BindEnv(config.Config{}, config.ClientRootConfig{}))
+
+	cfg, err = config.Decode[config.Config]()
+	require.NoError(t, err)
+	require.Equal(t, "not-a-real-key", cfg.Identity.Key)
+	require.Equal(t, uint(4000), cfg.Server.Port)
+
+	client, err := config.Decode[config.ClientRootConfig]()
+	require.NoError(t, err)
+	require.Equal(t, "https://node.example.com", client.Client.URL)
+
+	for _, s := range config.Settings(cfg, nil) {
+		if s.Key == "identity.key" {
+			require.Equal(t, "env PADRON_IDENTITY_KEY", s.Source)
+			require.Equal(t, "<redacted>", s.Value)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var a config.Config
+	a.Server.Listen = []string{"localhost:3000"}
+	a.Identity.RetiredKeys = []config.RetiredKeyConfig{{KeyFile: "old.pem", ValidUntil: "2026-12-31T00:00:00Z"}}
+
+	t.Run("no changes", func(t *testing.T) {
+		require.Empty(t, config.Diff(a, a))
+	})
+
+	t.Run("reports changed keys", func(t *testing.T) {
+		b := a
+		b.Server.Listen = []string{"localhost:3000", "unix:/run/padron.sock"}
+		b.Identity.RetiredKeys = nil
+		b.Logging.Level = "debug"
+		require.ElementsMatch(t, []string{"server.listen", "identity.retired_keys", "logging.level"}, config.Diff(a, b))
+	})
+}
+
+func TestAdminTokenFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "admin-token")
+
+	t.Run("reads the token", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("0123456789abcdef\n"), 0o600))
+		cfg, err := config.AdminConfig{TokenFile: file}.ToAppConfig()
+		require.NoError(t, err)
+		require.Equal(t, "0123456789abcdef", cfg.Token)
+	})
+
+	t.Run("rejects short tokens", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("short\n"), 0o600))
+		_, err := config.AdminConfig{TokenFile: file}.ToAppConfig()
+		require.ErrorContains(t, err, "at least 16 characters")
+	})
+
+	t.Run("not both a token and a token file", func(t *testing.T) {
+		require.Error(t, config.AdminConfig{Token: "0123456789abcdef", TokenFile: file}.Validate())
+	})
+}
@@ -42,6 +42,9 @@ func (r StoreConfig) ToAppConfig() (app.StoreConfig, error) {
 		Acceptance: app.AcceptanceStoreConfig{
 			Dir: filepath.Join(r.DataDir, "acceptance"),
 		},
+		Commitments: app.CommitmentStoreConfig{
+			Dir: filepath.Join(r.DataDir, "commitment"),
+		},
 	}
 
 	return out, nil
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter" validate:"omitempty,oneof=none stdout file otlp" flag:"tracing-exporter" toml:"exporter"`
+	File        string  `mapstructure:"file" validate:"required_if=Exporter file" flag:"tracing-file" toml:"file,omitempty"`
+	Endpoint    string  `mapstructure:"endpoint" validate:"omitempty,url" flag:"tracing-endpoint" toml:"endpoint,omitempty"`
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"min=0,max=1" flag:"tracing-sample-ratio" toml:"sample_ratio"`
+}
+
+func (t TracingConfig) Validate() error {
+	return validateConfig(t)
+}
+
+func (t TracingConfig) ToAppConfig() (app.TracingConfig, error) {
+	exporter := t.Exporter
+	if exporter == "" {
+		exporter = "none"
+	}
+	return app.TracingConfig{
+		Exporter:    exporter,
+		File:        t.File,
+		Endpoint:    t.Endpoint,
+		SampleRatio: t.SampleRatio,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"time"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+type UCANConfig struct {
+	ClockSkew time.Duration `mapstructure:"clock_skew" validate:"min=0" flag:"clock-skew" toml:"clock_skew"`
+}
+
+func (u UCANConfig) Validate() error {
+	return validateConfig(u)
+}
+
+func (u UCANConfig) ToAppConfig() (app.UCANConfig, error) {
+	return app.UCANConfig{
+		ClockSkew: u.ClockSkew,
+		Now:       time.Now,
+	}, nil
+}
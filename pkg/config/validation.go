@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
@@ -34,10 +35,30 @@ func formatValidationError(errs validator.ValidationErrors) error {
 		key := canonicalKey(err)
 		source := sourceInfo(key)
 		value := err.Value()
+		if isSecret(key) {
+			value = redacted
+		}
 
 		switch err.Tag() {
 		case "required":
 			messages = append(messages, fmt.Sprintf("%s is required but not provided (%s)", key, source))
+		case "required_with":
+			messages = append(messages, fmt.Sprintf("%s is required when %s is provided (%s)", key, siblingKey(key, err.Param()), source))
+		case "excluded_without":
+			messages = append(messages, fmt.Sprintf("%s can only be set together with %s (%s)", key, siblingKey(key, err.Param()), source))
+		case "required_without":
+			messages = append(messages, fmt.Sprintf("%s is required when %s is not provided (%s)", key, siblingKey(key, err.Param()), source))
+		case "required_without_all":
+			var others []string
+			for _, f := range strings.Fields(err.Param()) {
+				others = append(others, siblingKey(key, f))
+			}
+			messages = append(messages, fmt.Sprintf("%s is required when none of %s are provided (%s)", key, strings.Join(others, ", "), source))
+		case "required_if":
+			field, val, _ := strings.Cut(err.Param(), " ")
+			messages = append(messages, fmt.Sprintf("%s is required when %s is %s (%s)", key, siblingKey(key, field), val, source))
+		case "excluded_with":
+			messages = append(messages, fmt.Sprintf("%s cannot be set together with %s (%s)", key, siblingKey(key, err.Param()), source))
 		case "url":
 			messages = append(messages, fmt.Sprintf("%s must be a valid URL (%s)", key, source))
 		case "min":
@@ -108,6 +129,9 @@ func sourceInfo(key string) string {
 	// Check env var
 	envKey := envVarForKey(key)
 	if val, ok := os.LookupEnv(envKey); ok {
+		if isSecret(key) {
+			val = redacted
+		}
 		parts = append(parts, fmt.Sprintf("env %s=%s", envKey, val))
 	}
 
@@ -135,3 +159,35 @@ func envVarForKey(key string) string {
 	}
 	return key
 }
+
+const redacted = "<redacted>"
+
+// secretKeys are the config keys whose values must never be printed.
+var secretKeys = map[string]bool{
+	"identity.key":        true,
+	"identity.passphrase": true,
+	"admin.token":         true,
+}
+
+func isSecret(key string) bool {
+	return secretKeys[key]
+}
+
+// siblingKey returns the key of the field named by a cross-field validation
+// tag parameter, a Go field name, in the same section as key.
+func siblingKey(key, field string) string {
+	var b strings.Builder
+	for i, r := range field {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i+1] + b.String()
+	}
+	return b.String()
+}
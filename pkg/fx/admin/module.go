@@ -0,0 +1,57 @@
+package admin
+
+import (
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	"github.com/volmedo/padron/pkg/reload"
+	"github.com/volmedo/padron/pkg/server/admin"
+)
+
+var log = logging.Logger("fx/admin")
+
+// Module serves the admin endpoints under /admin, when an admin token is
+// configured. The token can be set, changed or removed by reloading the
+// config. When the server verifies TLS client certificates, admin requests
+// must present one as well.
+var Module = fx.Module("admin",
+	fx.Provide(
+		fx.Annotate(
+			NewServer,
+			fx.As(new(echofx.RouteRegistrar)),
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+	),
+)
+
+var _ echofx.RouteRegistrar = (*Server)(nil)
+
+type Server struct {
+	config *reload.Provider
+}
+
+func NewServer(config *reload.Provider) *Server {
+	return &Server{config: config}
+}
+
+func (s *Server) token() string {
+	return s.config.Config().Admin.Token
+}
+
+func (s *Server) RegisterRoutes(e *echo.Echo) {
+	if s.token() == "" {
+		log.Info("No admin token configured, admin endpoints are disabled")
+	}
+
+	middlewares := []echo.MiddlewareFunc{admin.Authenticate(s.token)}
+	if s.config.Config().Server.TLS.ClientCAs != nil {
+		log.Info("Admin endpoints require a TLS client certificate")
+		middlewares = append(middlewares, admin.RequireClientCert())
+	}
+
+	g := e.Group("/admin", middlewares...)
+	g.GET("/logging", admin.NewLoggingGetHandler())
+	g.PATCH("/logging", admin.NewLoggingPatchHandler())
+}
@@ -4,9 +4,15 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/fx/admin"
 	"github.com/volmedo/padron/pkg/fx/echo"
+	"github.com/volmedo/padron/pkg/fx/health"
 	"github.com/volmedo/padron/pkg/fx/identity"
+	"github.com/volmedo/padron/pkg/fx/metrics"
+	"github.com/volmedo/padron/pkg/fx/ratelimit"
+	"github.com/volmedo/padron/pkg/fx/reload"
 	"github.com/volmedo/padron/pkg/fx/store"
+	"github.com/volmedo/padron/pkg/fx/tracing"
 )
 
 func CommonModules(cfg app.AppConfig) fx.Option {
@@ -17,10 +23,25 @@ func CommonModules(cfg app.AppConfig) fx.Option {
 		fx.Supply(cfg),
 		fx.Supply(cfg.Identity),
 		fx.Supply(cfg.Server),
+		fx.Supply(cfg.HTTP),
 		fx.Supply(cfg.Stores),
+		fx.Supply(cfg.UCAN),
+		fx.Supply(cfg.Blob),
+		fx.Supply(cfg.Metrics),
+		fx.Supply(cfg.Tracing),
+		fx.Supply(cfg.Health),
+		fx.Supply(cfg.Logging),
+		fx.Supply(cfg.Admin),
+		fx.Supply(cfg.RateLimit),
 
-		identity.Module, // Provides principal.Signer
-		echo.Module,     // Provides Echo server with route registration
+		identity.Module,  // Provides principal.Signer
+		reload.Module,    // Provides the reloadable config, needs config.Config, a reload.Loader and a reload.ConfigFile supplied
+		echo.Module,      // Provides Echo server with route registration
+		metrics.Module,   // Serves the metrics endpoint
+		tracing.Module,   // Configures span export
+		health.Module,    // Serves liveness and readiness endpoints
+		admin.Module,     // Serves authenticated admin endpoints
+		ratelimit.Module, // Provides per DID and per IP rate limiters
 	}
 
 	if cfg.Stores.DataDir == "" {
@@ -1,6 +1,10 @@
 package blob
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/alanshaw/ucantone/principal"
 	"github.com/labstack/echo/v4"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
@@ -10,9 +14,13 @@ import (
 
 	"github.com/volmedo/padron/pkg/config/app"
 	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	ratelimitfx "github.com/volmedo/padron/pkg/fx/ratelimit"
 	blobsvr "github.com/volmedo/padron/pkg/server/blob"
 	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
 	blobucan "github.com/volmedo/padron/pkg/ucan/blob"
+	"github.com/volmedo/padron/pkg/ucanto"
+	ucantoblob "github.com/volmedo/padron/pkg/ucanto/blob"
 )
 
 var Module = fx.Module("blob",
@@ -26,39 +34,95 @@ var Module = fx.Module("blob",
 			blobucan.NewBlobAcceptHandler,
 			fx.ResultTags(`group:"ucan_handlers"`),
 		),
+		fx.Annotate(
+			ucantoblob.NewBlobAllocateMethod,
+			fx.ResultTags(`group:"ucanto_methods"`),
+		),
+		fx.Annotate(
+			ucantoblob.NewBlobAcceptMethod,
+			fx.ResultTags(`group:"ucanto_methods"`),
+		),
 		fx.Annotate(
 			NewBlobServer,
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
 	),
+	fx.Invoke(RunCommitmentRenewer),
 )
 
 func NewBlobService(
 	cfg app.ServerConfig,
+	ucanCfg app.UCANConfig,
+	blobCfg app.BlobConfig,
 	id principal.Signer,
 	blobs blobstore.Blobstore,
 	allocs allocationstore.AllocationStore,
 	acceptances acceptancestore.AcceptanceStore,
-) *blobsvc.Service {
-	return blobsvc.NewService(id, cfg.PublicURL, blobs, allocs, acceptances)
+	commitments commitmentstore.CommitmentStore,
+) (*blobsvc.Service, error) {
+	legacyID, err := ucanto.ToSigner(id)
+	if err != nil {
+		return nil, fmt.Errorf("creating ucanto signer: %w", err)
+	}
+
+	return blobsvc.NewService(
+		id,
+		cfg.PublicURL,
+		blobs,
+		allocs,
+		acceptances,
+		commitments,
+		blobsvc.WithClock(ucanCfg.Now),
+		blobsvc.WithMirrorURLs(cfg.MirrorURLs...),
+		blobsvc.WithCommitmentTTL(blobCfg.CommitmentTTL),
+		blobsvc.WithLegacyCommitter(ucantoblob.NewLocationCommitter(legacyID)),
+	), nil
+}
+
+// RunCommitmentRenewer renews the location commitments issued by the blob
+// service for the lifetime of the app.
+func RunCommitmentRenewer(cfg app.BlobConfig, svc *blobsvc.Service, lc fx.Lifecycle) {
+	r := blobsvc.NewRenewer(svc, cfg.RenewInterval, cfg.RenewBefore)
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			r.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			r.Stop()
+			return nil
+		},
+	})
 }
 
 var _ echofx.RouteRegistrar = (*Server)(nil)
 
 type Server struct {
-	allocs allocationstore.AllocationStore
-	blobs  blobstore.Blobstore
+	allocs      allocationstore.AllocationStore
+	blobs       blobstore.Blobstore
+	commitments commitmentstore.CommitmentStore
+	now         func() time.Time
+	http        app.HTTPConfig
+	limit       echo.MiddlewareFunc
 }
 
-func NewBlobServer(allocs allocationstore.AllocationStore, blobs blobstore.Blobstore) *Server {
+func NewBlobServer(cfg app.UCANConfig, httpCfg app.HTTPConfig, limiters *ratelimitfx.Limiters, allocs allocationstore.AllocationStore, blobs blobstore.Blobstore, commitments commitmentstore.CommitmentStore) *Server {
 	return &Server{
-		allocs: allocs,
-		blobs:  blobs,
+		allocs:      allocs,
+		blobs:       blobs,
+		commitments: commitments,
+		now:         cfg.Now,
+		http:        httpCfg,
+		limit:       echofx.RateLimit(limiters.IP, echofx.ClientIP(limiters.TrustForwarded)),
 	}
 }
 
+// RegisterRoutes registers the blob transfer routes, with their own timeouts
+// instead of the API ones, and the location commitment route, all rate limited
+// per client IP.
 func (srv *Server) RegisterRoutes(e *echo.Echo) {
-	e.GET("/blob/:blob", blobsvr.NewBlobGetHandler(srv.blobs))
-	e.PUT("/blob/:blob", blobsvr.NewBlobPutHandler(srv.allocs, srv.blobs))
+	e.GET("/blob/:blob/commitment/:space", blobsvr.NewCommitmentGetHandler(srv.commitments), srv.limit)
+	e.GET("/blob/:blob", blobsvr.NewBlobGetHandler(srv.blobs), srv.limit, echofx.Deadlines(srv.http.ReadTimeout, srv.http.DownloadTimeout))
+	e.PUT("/blob/:blob", blobsvr.NewBlobPutHandler(srv.allocs, srv.blobs, srv.now), srv.limit, echofx.Deadlines(srv.http.UploadTimeout, srv.http.UploadTimeout))
 }
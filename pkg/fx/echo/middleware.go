@@ -1,13 +1,19 @@
 package echo
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
 )
 
 // ErrorLogger is a middleware that logs errors to the provided logger.
@@ -40,8 +46,10 @@ func RequestLogger(logger *logging.ZapEventLogger) echo.MiddlewareFunc {
 		LogResponseSize:  true,
 		LogHeaders:       []string{"X-UCAN-Container"},
 		LogError:         true,
+		LogRequestID:     true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
 			fields := []zap.Field{
+				zap.String("request_id", v.RequestID),
 				zap.Int("status", v.Status),
 				zap.String("method", v.Method),
 				zap.String("uri", v.URI),
@@ -68,3 +76,98 @@ func RequestLogger(logger *logging.ZapEventLogger) echo.MiddlewareFunc {
 		},
 	})
 }
+
+// Deadlines is a middleware that limits the time allowed to read the request
+// and write the response, from the moment the handler is called. A zero
+// duration removes the deadline, so route specific deadlines can replace
+// those set for all routes.
+func Deadlines(read, write time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			rc := http.NewResponseController(c.Response())
+			now := time.Now()
+			if err := rc.SetReadDeadline(deadline(now, read)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+			if err := rc.SetWriteDeadline(deadline(now, write)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+func deadline(now time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return now.Add(d)
+}
+
+// BodyLimit is a middleware that rejects request bodies larger than limit
+// bytes with 413 Request Entity Too Large. A zero limit disables it.
+//
+// Bodies of unknown length are read up front, so that handlers, and the codecs
+// decoding them, never see a body cut at the limit.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if limit <= 0 {
+				return next(c)
+			}
+			tooLarge := echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d bytes", limit))
+			req := c.Request()
+			if req.ContentLength > limit {
+				return tooLarge
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			if req.ContentLength < 0 {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					var maxBytesErr *http.MaxBytesError
+					if errors.As(err, &maxBytesErr) {
+						return tooLarge
+					}
+					return echo.NewHTTPError(http.StatusBadRequest, "reading request body").SetInternal(err)
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				req.ContentLength = int64(len(body))
+			}
+			err := next(c)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return tooLarge
+			}
+			return err
+		}
+	}
+}
+
+// RateLimit is a middleware that limits the rate of requests of each client,
+// as identified by key. Requests over the limit are rejected with 429 Too Many
+// Requests and a Retry-After header.
+func RateLimit(limiter *ratelimit.Limiter, key func(c echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if ok, wait := limiter.Allow(key(c)); !ok {
+				c.Response().Header().Set("Retry-After", ratelimit.RetryAfter(wait))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+// ClientIP keys requests by the IP address of the client. Addresses in the
+// X-Forwarded-For and X-Real-IP headers are only used if trustForwarded
+// reports true, as clients can set them at will. It is called for every
+// request, so the setting can be reloaded.
+func ClientIP(trustForwarded func() bool) func(c echo.Context) string {
+	direct := echo.ExtractIPDirect()
+	return func(c echo.Context) string {
+		if trustForwarded() {
+			return c.RealIP()
+		}
+		return direct(c.Request())
+	}
+}
@@ -0,0 +1,180 @@
+package echo_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	"github.com/volmedo/padron/pkg/ratelimit"
+)
+
+func TestBodyLimit(t *testing.T) {
+	e := echo.New()
+	var received []byte
+	e.POST("/", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		received = body
+		return c.NoContent(http.StatusNoContent)
+	}, echofx.BodyLimit(8))
+
+	post := func(body io.Reader, length int64) int {
+		received = nil
+		req := httptest.NewRequest(http.MethodPost, "/", body)
+		req.ContentLength = length
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, post(strings.NewReader("12345678"), 8))
+		require.Equal(t, []byte("12345678"), received)
+	})
+
+	t.Run("content length over the limit", func(t *testing.T) {
+		require.Equal(t, http.StatusRequestEntityTooLarge, post(strings.NewReader("123456789"), 9))
+		require.Nil(t, received)
+	})
+
+	t.Run("unknown length within the limit", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, post(strings.NewReader("1234"), -1))
+		require.Equal(t, []byte("1234"), received)
+	})
+
+	t.Run("unknown length over the limit", func(t *testing.T) {
+		require.Equal(t, http.StatusRequestEntityTooLarge, post(strings.NewReader("123456789"), -1))
+		require.Nil(t, received, "handler called")
+	})
+
+	t.Run("maps body limit errors of handlers", func(t *testing.T) {
+		e := echo.New()
+		e.POST("/", func(c echo.Context) error {
+			return &http.MaxBytesError{Limit: 8}
+		}, echofx.BodyLimit(8))
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(nil)))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		e := echo.New()
+		e.POST("/", func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		}, echofx.BodyLimit(0))
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456789")))
+		require.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
+
+func TestDeadlines(t *testing.T) {
+	// serve reads the body of a request sent after delay through a server
+	// with the given read timeout and route deadlines.
+	serve := func(t *testing.T, timeout, read, delay time.Duration) error {
+		t.Helper()
+
+		readErr := make(chan error, 1)
+		e := echo.New()
+		e.POST("/", func(c echo.Context) error {
+			_, err := io.ReadAll(c.Request().Body)
+			readErr <- err
+			return c.NoContent(http.StatusNoContent)
+		}, echofx.Deadlines(read, 0))
+
+		srv := httptest.NewUnstartedServer(e)
+		srv.Config.ReadTimeout = timeout
+		srv.Start()
+		defer srv.Close()
+
+		body, w := io.Pipe()
+		go func() {
+			time.Sleep(delay)
+			w.Write([]byte("late"))
+			w.Close()
+		}()
+		req, err := http.NewRequest(http.MethodPost, srv.URL, body)
+		require.NoError(t, err)
+		resp, err := srv.Client().Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case err := <-readErr:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler not called")
+			return nil
+		}
+	}
+
+	t.Run("read deadline", func(t *testing.T) {
+		err := serve(t, 0, 50*time.Millisecond, 500*time.Millisecond)
+		var netErr interface{ Timeout() bool }
+		require.True(t, errors.As(err, &netErr) && netErr.Timeout(), "expected a timeout, got %v", err)
+	})
+
+	t.Run("zero removes the server deadline", func(t *testing.T) {
+		require.NoError(t, serve(t, 50*time.Millisecond, 0, 200*time.Millisecond))
+	})
+
+	t.Run("within the deadline", func(t *testing.T) {
+		require.NoError(t, serve(t, 0, time.Second, 50*time.Millisecond))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	e := echo.New()
+	key := func(c echo.Context) string { return c.Request().Header.Get("X-Client") }
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, echofx.RateLimit(ratelimit.New(0.001, 1), key))
+
+	get := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client", client)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusNoContent, get("alice").Code)
+
+	rec := get("alice")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1000", rec.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusNoContent, get("bob").Code)
+}
+
+func TestClientIP(t *testing.T) {
+	var trust atomic.Bool
+	clientIP := echofx.ClientIP(trust.Load)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	require.Equal(t, "10.0.0.1", clientIP(c))
+
+	// the setting is read for every request, so it can be reloaded
+	trust.Store(true)
+	require.Equal(t, "203.0.113.7", clientIP(c))
+}
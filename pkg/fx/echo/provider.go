@@ -3,7 +3,7 @@ package echo
 import (
 	"context"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
 
 	logging "github.com/ipfs/go-log/v2"
@@ -12,6 +12,11 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/listener"
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/server"
+	"github.com/volmedo/padron/pkg/tracing"
 )
 
 var log = logging.Logger("fx/echo")
@@ -19,10 +24,12 @@ var log = logging.Logger("fx/echo")
 var Module = fx.Module("echo",
 	fx.Provide(
 		NewEcho,
+		StartEchoServer,
 	),
 	fx.Invoke(
 		RegisterRoutes,
-		StartEchoServer,
+		// the server is started even if nothing depends on it
+		func(*EchoServer) {},
 	),
 )
 
@@ -31,44 +38,90 @@ type RouteRegistrar interface {
 	RegisterRoutes(e *echo.Echo)
 }
 
-// NewEcho creates a new Echo instance with default middleware
-func NewEcho() *echo.Echo {
+// NewEcho creates a new Echo instance with default middleware. API timeouts
+// apply to every route, unless replaced by a route specific [Deadlines].
+func NewEcho(cfg app.HTTPConfig) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
 
+	e.Use(requestid.Middleware())
+	e.Use(tracing.Middleware())
+	e.Use(metrics.Middleware())
 	e.Use(RequestLogger(log))
 	e.Use(middleware.Recover())
 	e.Use(ErrorLogger(log))
+	e.Use(Deadlines(cfg.ReadTimeout, cfg.WriteTimeout))
 
 	return e
 }
 
 // EchoServer wraps Echo with fx lifecycle management
 type EchoServer struct {
-	echo *echo.Echo
-	addr string
+	echo      *echo.Echo
+	listeners []net.Listener
 }
 
-// StartEchoServer runs a Echo server with lifecycle management
+// StartEchoServer runs a Echo server with lifecycle management. The configured
+// addresses are bound when the app starts, so a failure to bind fails startup.
+// It terminates TLS when configured, and speaks HTTP/2 over TLS, and without
+// it if h2c is enabled.
 func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle) (*EchoServer, error) {
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(cfg.Server.H2C)
+
+	// read and write timeouts are set per route, see NewEcho
+	httpServer := &http.Server{
+		Handler:           e,
+		Protocols:         protocols,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:    cfg.HTTP.MaxHeaderBytes,
+	}
+	if cfg.Server.TLS.Enabled() {
+		certs, err := server.NewCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		httpServer.TLSConfig = server.NewTLSConfig(certs, cfg.Server.TLS.ClientCAs)
+	}
+	e.Server = httpServer
 
-	server := &EchoServer{
+	echoServer := &EchoServer{
 		echo: e,
-		addr: addr,
 	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			log.Infof("Starting Echo server on %s", addr)
-
-			// Start server in a goroutine
-			go func() {
-				if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
-					log.Errorf("Echo server error: %v", err)
-				}
-			}()
+			var options []listener.Option
+			if cfg.Server.UnixSocketMode != 0 {
+				options = append(options, listener.WithUnixSocketMode(cfg.Server.UnixSocketMode))
+			}
+			listeners, err := listener.Listen(cfg.Server.Listen, options...)
+			if err != nil {
+				return err
+			}
+			echoServer.listeners = listeners
+
+			for _, l := range listeners {
+				log.Infow("Starting Echo server", "addr", listener.String(l), "tls", cfg.Server.TLS.Enabled(), "h2c", cfg.Server.H2C)
+
+				// Serve in a goroutine, the listener is already bound
+				go func() {
+					var err error
+					if httpServer.TLSConfig != nil {
+						// certificates are served by the TLS config
+						err = httpServer.ServeTLS(l, "", "")
+					} else {
+						err = httpServer.Serve(l)
+					}
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						log.Errorf("Echo server error on %s: %v", listener.String(l), err)
+					}
+				}()
+			}
 
 			return nil
 		},
@@ -93,7 +146,7 @@ func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle) (*EchoSer
 		},
 	})
 
-	return server, nil
+	return echoServer, nil
 }
 
 // RouteParams collects all route registrars
@@ -112,7 +165,11 @@ func RegisterRoutes(e *echo.Echo, params RouteParams) {
 	}
 }
 
-// Address returns the server's listening address
-func (s *EchoServer) Address() string {
-	return s.addr
+// Addresses returns the addresses the server listens on, once started.
+func (s *EchoServer) Addresses() []string {
+	addrs := make([]string, 0, len(s.listeners))
+	for _, l := range s.listeners {
+		addrs = append(addrs, listener.String(l))
+	}
+	return addrs
 }
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"github.com/alanshaw/ucantone/principal"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	"github.com/volmedo/padron/pkg/health"
+	"github.com/volmedo/padron/pkg/reload"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+var log = logging.Logger("fx/health")
+
+// Module serves the /healthz and /readyz endpoints, and fails readiness as
+// soon as the node starts shutting down.
+//
+// It must be included after the echo module: fx runs stop hooks in reverse
+// order, so the node starts draining before the HTTP server is shut down.
+var Module = fx.Module("health",
+	fx.Provide(
+		NewChecker,
+		fx.Annotate(
+			NewHandler,
+			fx.As(new(echofx.RouteRegistrar)),
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+	),
+	fx.Invoke(DrainOnStop),
+)
+
+// CheckerParams collects the dependencies checked for readiness.
+type CheckerParams struct {
+	fx.In
+
+	Config      *reload.Provider
+	Stores      app.StoreConfig
+	ID          principal.Signer
+	Allocations allocationstore.AllocationStore
+	Acceptances acceptancestore.AcceptanceStore
+	Commitments commitmentstore.CommitmentStore
+	Blobs       blobstore.Blobstore
+}
+
+// NewChecker creates a readiness checker for the node stores, temporary
+// directory, capacity and identity.
+func NewChecker(p CheckerParams) *health.Checker {
+	options := []health.Option{
+		health.WithCheck("identity", health.IdentityCheck(p.ID)),
+		health.WithCheck("allocation_store", health.AllocationStoreCheck(p.Allocations)),
+		health.WithCheck("acceptance_store", health.AcceptanceStoreCheck(p.Acceptances)),
+		health.WithCheck("commitment_store", health.CommitmentStoreCheck(p.Commitments, p.ID)),
+		health.WithCheck("blob_store", health.BlobStoreCheck(p.Blobs)),
+		health.WithCheck("temp_dir", health.WritableDirCheck(p.Stores.TempDir)),
+	}
+	// capacity only matters when blobs are persisted to disk
+	if p.Stores.DataDir != "" {
+		options = append(options, health.WithCheck("capacity", func(ctx context.Context) error {
+			// the minimum free space can be changed by reloading the config
+			return health.CapacityCheck(p.Stores.DataDir, p.Config.Config().Health.MinFreeSpace)(ctx)
+		}))
+	}
+	return health.NewChecker(options...)
+}
+
+var _ echofx.RouteRegistrar = (*Handler)(nil)
+
+type Handler struct {
+	checker *health.Checker
+}
+
+func NewHandler(checker *health.Checker) *Handler {
+	return &Handler{checker: checker}
+}
+
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/healthz", echo.WrapHandler(health.LivenessHandler()))
+	e.GET("/readyz", echo.WrapHandler(h.checker.ReadinessHandler()))
+}
+
+// DrainOnStop fails readiness when the node is stopped, and waits for the
+// configured drain delay before the rest of the shutdown proceeds.
+func DrainOnStop(config *reload.Provider, checker *health.Checker, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			checker.Drain()
+			delay := config.Config().Health.DrainDelay
+			if delay <= 0 {
+				return nil
+			}
+
+			log.Infof("Waiting %s for in-flight traffic to drain", delay)
+			select {
+			case <-time.After(delay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
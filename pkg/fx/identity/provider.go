@@ -1,17 +1,113 @@
 package identity
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/alanshaw/ucantone/principal"
+	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
 
 	"github.com/volmedo/padron/pkg/config/app"
+	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	"github.com/volmedo/padron/pkg/server"
+	"github.com/volmedo/padron/pkg/succession"
 )
 
 var Module = fx.Module("identity",
-	fx.Provide(ProvideIdentity),
+	fx.Provide(
+		ProvideIdentity,
+		fx.Annotate(
+			NewHandler,
+			fx.As(new(echofx.RouteRegistrar)),
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+	),
 )
 
-// ProvideIdentity extracts the principal signer from the app config
-func ProvideIdentity(cfg app.AppConfig) principal.Signer {
+// ProvideIdentity extracts the principal signer from the app config. Signers
+// holding resources, such as the connection to a key agent, are closed when
+// the app stops.
+func ProvideIdentity(lc fx.Lifecycle, cfg app.AppConfig) principal.Signer {
+	if c, ok := cfg.Identity.Key().(io.Closer); ok {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return c.Close()
+			},
+		})
+	}
 	return cfg.Identity.Signer
 }
+
+var _ echofx.RouteRegistrar = (*Handler)(nil)
+
+// Handler serves the DID document of a did:web identity, and the succession
+// statements of retired keys.
+type Handler struct {
+	id         app.IdentityConfig
+	now        func() time.Time
+	statements []statement
+}
+
+type statement struct {
+	signed     *succession.Signed
+	validUntil time.Time
+}
+
+func NewHandler(cfg app.IdentityConfig, ucanCfg app.UCANConfig) (*Handler, error) {
+	h := &Handler{id: cfg, now: ucanCfg.Now}
+	if h.now == nil {
+		h.now = time.Now
+	}
+	issued := h.now()
+	for _, rk := range cfg.RetiredKeys {
+		st := succession.New(cfg.Signer.DID().String(), rk.Signer, cfg.Key(), rk.ValidUntil, issued)
+		signed, err := succession.Sign(rk.Signer, cfg.Key(), st)
+		if err != nil {
+			return nil, fmt.Errorf("signing succession of retired key %s: %w", rk.Signer.DID(), err)
+		}
+		h.statements = append(h.statements, statement{signed: signed, validUntil: rk.ValidUntil})
+	}
+	return h, nil
+}
+
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	if strings.HasPrefix(h.id.Signer.DID().String(), "did:web:") {
+		e.GET(server.DIDDocumentPath, echo.WrapHandler(http.HandlerFunc(h.serveDIDDocument)))
+	}
+	if len(h.statements) > 0 {
+		e.GET(succession.Path, h.getSuccession)
+	}
+}
+
+// serveDIDDocument lists the keys valid at the time of the request.
+func (h *Handler) serveDIDDocument(w http.ResponseWriter, r *http.Request) {
+	keys := h.id.ValidKeys(h.now())
+	verifiers := make([]principal.Verifier, 0, len(keys))
+	for _, k := range keys {
+		verifiers = append(verifiers, k.Verifier())
+	}
+	server.NewDIDDocumentHandler(h.id.Signer.DID(), verifiers...).ServeHTTP(w, r)
+}
+
+// getSuccession lists the succession statements of keys still valid.
+func (h *Handler) getSuccession(c echo.Context) error {
+	now := h.now()
+	signed := []*succession.Signed{}
+	for _, st := range h.statements {
+		if now.Before(st.validUntil) {
+			signed = append(signed, st.signed)
+		}
+	}
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSONBlob(http.StatusOK, data)
+}
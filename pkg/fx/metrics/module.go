@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/metrics"
+)
+
+var log = logging.Logger("fx/metrics")
+
+// Module exposes the Prometheus metrics endpoint, either on the main HTTP
+// server or on a separate listener.
+var Module = fx.Module("metrics",
+	fx.Invoke(
+		RegisterCapacity,
+		ServeMetrics,
+	),
+)
+
+// RegisterCapacity registers filesystem capacity gauges for the data directory,
+// when stores are persisted to disk.
+func RegisterCapacity(cfg app.StoreConfig) error {
+	if cfg.DataDir == "" {
+		return nil
+	}
+	if err := metrics.RegisterCapacity(cfg.DataDir); err != nil {
+		return fmt.Errorf("registering capacity metrics: %w", err)
+	}
+	return nil
+}
+
+// ServeMetrics serves the metrics endpoint at /metrics.
+func ServeMetrics(cfg app.MetricsConfig, e *echo.Echo, lc fx.Lifecycle) {
+	if cfg.Addr == "" {
+		e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: cfg.Addr, Handler: mux}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", cfg.Addr)
+			if err != nil {
+				return fmt.Errorf("listening on %s: %w", cfg.Addr, err)
+			}
+
+			log.Infof("Serving metrics on %s", cfg.Addr)
+			go func() {
+				if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Errorf("Metrics server error: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/ratelimit"
+	"github.com/volmedo/padron/pkg/reload"
+)
+
+// Module provides the rate limiters of the node, which follow changes to the
+// rate limit config when it is reloaded.
+var Module = fx.Module("ratelimit",
+	fx.Provide(NewLimiters),
+)
+
+// Limiters are the rate limiters of the node.
+type Limiters struct {
+	// DID limits UCAN invocations by issuer.
+	DID *ratelimit.Limiter
+	// IP limits blob and invocation requests by client IP address.
+	IP *ratelimit.Limiter
+
+	trustForwarded atomic.Bool
+}
+
+// TrustForwarded reports whether client IP addresses are taken from
+// forwarding headers, as currently configured.
+func (l *Limiters) TrustForwarded() bool {
+	return l.trustForwarded.Load()
+}
+
+func NewLimiters(config *reload.Provider) *Limiters {
+	cfg := config.Config().RateLimit
+	l := &Limiters{
+		DID: ratelimit.New(cfg.DIDRate, cfg.DIDBurst),
+		IP:  ratelimit.New(cfg.IPRate, cfg.IPBurst),
+	}
+	l.trustForwarded.Store(cfg.TrustForwarded)
+	config.Subscribe(func(cfg app.AppConfig) {
+		l.DID.SetLimit(cfg.RateLimit.DIDRate, cfg.RateLimit.DIDBurst)
+		l.IP.SetLimit(cfg.RateLimit.IPRate, cfg.RateLimit.IPBurst)
+		l.trustForwarded.Store(cfg.RateLimit.TrustForwarded)
+	})
+	return l
+}
@@ -0,0 +1,106 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/logs"
+	"github.com/volmedo/padron/pkg/reload"
+)
+
+var log = logging.Logger("fx/reload")
+
+// Module provides the current config of the node as a *reload.Provider, and
+// reloads it when the process receives SIGHUP, or when the config file changes
+// if it is watched. It depends on the user config the node was started with,
+// a reload.Loader and a ConfigFile being supplied.
+var Module = fx.Module("reload",
+	fx.Provide(NewProvider),
+	fx.Invoke(ApplyLogging, ReloadOnSignal, ReloadOnChange),
+)
+
+// ConfigFile is the path of the config file to watch for changes, or empty to
+// not watch it.
+type ConfigFile string
+
+func NewProvider(user config.Config, cfg app.AppConfig) *reload.Provider {
+	return reload.NewProvider(user, cfg)
+}
+
+// ApplyLogging sets up logging again when its config changes. Levels changed
+// at runtime through the admin endpoints are kept otherwise.
+func ApplyLogging(p *reload.Provider) {
+	last := p.Config().Logging
+	p.Subscribe(func(cfg app.AppConfig) {
+		if reflect.DeepEqual(cfg.Logging, last) {
+			return
+		}
+		if err := logs.Setup(cfg.Logging); err != nil {
+			log.Errorw("Applying reloaded logging config", "error", err)
+			return
+		}
+		last = cfg.Logging
+	})
+}
+
+// ReloadOnSignal reloads the config every time the process receives SIGHUP.
+func ReloadOnSignal(lc fx.Lifecycle, p *reload.Provider, load reload.Loader) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(signals, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-signals:
+						log.Info("Received SIGHUP, reloading config")
+						if err := p.Reload(load); err != nil {
+							log.Errorw("Rejected reloaded config, keeping the current one", "error", err)
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(signals)
+			close(done)
+			return nil
+		},
+	})
+}
+
+// ReloadOnChange reloads the config every time the watched config file
+// changes. Reloads go through the same loader as SIGHUP, so the file is never
+// read concurrently.
+func ReloadOnChange(lc fx.Lifecycle, p *reload.Provider, load reload.Loader, file ConfigFile) {
+	if file == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return reload.Watch(ctx, string(file), func() {
+				log.Info("Config file changed, reloading config")
+				if err := p.Reload(load); err != nil {
+					log.Errorw("Rejected reloaded config, keeping the current one", "error", err)
+				}
+			})
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
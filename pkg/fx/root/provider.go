@@ -5,8 +5,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
 
+	"github.com/volmedo/padron/pkg/config/app"
 	echofx "github.com/volmedo/padron/pkg/fx/echo"
 	"github.com/volmedo/padron/pkg/server"
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/ucan"
 )
 
 var Module = fx.Module("root-handler",
@@ -22,13 +25,33 @@ var Module = fx.Module("root-handler",
 var _ echofx.RouteRegistrar = (*Handler)(nil)
 
 type Handler struct {
-	id principal.Signer
+	id      principal.Signer
+	options []server.RootOption
 }
 
-func NewRootHandler(id principal.Signer) *Handler {
-	return &Handler{id: id}
+type Params struct {
+	fx.In
+	ID       principal.Signer
+	Server   app.ServerConfig
+	Stores   app.StoreConfig
+	Handlers []*ucan.Handler `group:"ucan_handlers"`
+}
+
+func NewRootHandler(p Params) *Handler {
+	options := []server.RootOption{
+		server.WithPublicURL(p.Server.PublicURL),
+		server.WithMaxUploadSize(blobsvc.MaxUploadSize),
+		server.WithMultihashCodes(blobsvc.SupportedDigests...),
+	}
+	for _, h := range p.Handlers {
+		options = append(options, server.WithCommands(string(h.Capability.Command())))
+	}
+	if p.Stores.DataDir != "" {
+		options = append(options, server.WithStorageDir(p.Stores.DataDir))
+	}
+	return &Handler{id: p.ID, options: options}
 }
 
 func (h *Handler) RegisterRoutes(e *echo.Echo) {
-	e.GET("/", echo.WrapHandler(server.NewRootHandler(h.id)))
+	e.GET("/", echo.WrapHandler(server.NewRootHandler(h.id, h.options...)))
 }
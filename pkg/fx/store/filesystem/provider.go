@@ -13,6 +13,9 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/tracing"
 )
 
 var Module = fx.Module("filesystem-store",
@@ -20,6 +23,7 @@ var Module = fx.Module("filesystem-store",
 		ProvideConfigs,
 		NewAllocationStore,
 		NewAcceptanceStore,
+		NewCommitmentStore,
 		NewBlobStore,
 	),
 )
@@ -29,6 +33,7 @@ type Configs struct {
 	Blob       app.BlobStoreConfig
 	Allocation app.AllocationStoreConfig
 	Acceptance app.AcceptanceStoreConfig
+	Commitment app.CommitmentStoreConfig
 }
 
 // ProvideConfigs provides the fields of a storage config
@@ -37,6 +42,7 @@ func ProvideConfigs(cfg app.StoreConfig) Configs {
 		Allocation: cfg.Allocations,
 		Blob:       cfg.Blobs,
 		Acceptance: cfg.Acceptance,
+		Commitment: cfg.Commitments,
 	}
 }
 
@@ -53,7 +59,7 @@ func NewBlobStore(cfg app.BlobStoreConfig) (blobstore.Blobstore, error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating blob store: %w", err)
 	}
-	return bs, nil
+	return tracing.TraceBlobstore(metrics.InstrumentBlobstore(bs)), nil
 }
 
 func NewAllocationStore(cfg app.AllocationStoreConfig, lc fx.Lifecycle) (allocationstore.AllocationStore, error) {
@@ -72,7 +78,11 @@ func NewAllocationStore(cfg app.AllocationStoreConfig, lc fx.Lifecycle) (allocat
 		},
 	})
 
-	return allocationstore.NewDsAllocationStore(ds)
+	s, err := allocationstore.NewDsAllocationStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceAllocationStore(metrics.InstrumentAllocationStore(s)), nil
 }
 
 func NewAcceptanceStore(cfg app.AcceptanceStoreConfig, lc fx.Lifecycle) (acceptancestore.AcceptanceStore, error) {
@@ -91,7 +101,34 @@ func NewAcceptanceStore(cfg app.AcceptanceStoreConfig, lc fx.Lifecycle) (accepta
 		},
 	})
 
-	return acceptancestore.NewDsAcceptanceStore(ds)
+	s, err := acceptancestore.NewDsAcceptanceStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceAcceptanceStore(metrics.InstrumentAcceptanceStore(s)), nil
+}
+
+func NewCommitmentStore(cfg app.CommitmentStoreConfig, lc fx.Lifecycle) (commitmentstore.CommitmentStore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for commitment store")
+	}
+
+	ds, err := newDs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating commitment store: %w", err)
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	s, err := commitmentstore.NewDsCommitmentStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceCommitmentStore(metrics.InstrumentCommitmentStore(s)), nil
 }
 
 func newDs(path string) (*leveldb.Datastore, error) {
@@ -8,27 +8,49 @@ import (
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/tracing"
 )
 
 var Module = fx.Module("memory-store",
 	fx.Provide(
 		NewAllocationStore,
 		NewAcceptanceStore,
+		NewCommitmentStore,
 		NewBlobStore,
 	),
 )
 
 func NewAllocationStore() (allocationstore.AllocationStore, error) {
 	ds := sync.MutexWrap(datastore.NewMapDatastore())
-	return allocationstore.NewDsAllocationStore(ds)
+	s, err := allocationstore.NewDsAllocationStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceAllocationStore(metrics.InstrumentAllocationStore(s)), nil
 }
 
 func NewAcceptanceStore() (acceptancestore.AcceptanceStore, error) {
 	ds := sync.MutexWrap(datastore.NewMapDatastore())
-	return acceptancestore.NewDsAcceptanceStore(ds)
+	s, err := acceptancestore.NewDsAcceptanceStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceAcceptanceStore(metrics.InstrumentAcceptanceStore(s)), nil
+}
+
+func NewCommitmentStore() (commitmentstore.CommitmentStore, error) {
+	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	s, err := commitmentstore.NewDsCommitmentStore(ds)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceCommitmentStore(metrics.InstrumentCommitmentStore(s)), nil
 }
 
 func NewBlobStore() blobstore.Blobstore {
 	ds := sync.MutexWrap(datastore.NewMapDatastore())
-	return blobstore.NewDsBlobstore(ds)
+	return tracing.TraceBlobstore(metrics.InstrumentBlobstore(blobstore.NewDsBlobstore(ds)))
 }
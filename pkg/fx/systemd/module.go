@@ -0,0 +1,75 @@
+package systemd
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var log = logging.Logger("fx/systemd")
+
+// Module notifies systemd when the node is ready and stopping, and pings its
+// watchdog when enabled. It does nothing when the node is not run by systemd
+// with Type=notify.
+//
+// The watchdog only tracks liveness: it is pinged as long as the process is
+// running. Readiness, e.g. a full disk, is reported by /readyz instead, as
+// restarting the node would not fix it and would drop in-flight uploads.
+//
+// It must be included after the modules starting servers: fx runs start hooks
+// in order, so the node is only reported ready once it is serving.
+var Module = fx.Module("systemd",
+	fx.Invoke(Notify),
+)
+
+// Notify reports the lifecycle of the node to systemd.
+func Notify(lc fx.Lifecycle) {
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			notify(daemon.SdNotifyReady)
+
+			interval, err := daemon.SdWatchdogEnabled(false)
+			if err != nil {
+				log.Warnw("Reading systemd watchdog settings", "error", err)
+			}
+			if interval > 0 {
+				log.Infof("Pinging systemd watchdog every %s", interval/2)
+				go watchdog(interval/2, done)
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(done)
+			notify(daemon.SdNotifyStopping)
+			return nil
+		},
+	})
+}
+
+func watchdog(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			notify(daemon.SdNotifyWatchdog)
+		case <-done:
+			return
+		}
+	}
+}
+
+func notify(state string) {
+	sent, err := daemon.SdNotify(false, state)
+	if err != nil {
+		log.Warnw("Notifying systemd", "state", state, "error", err)
+		return
+	}
+	if sent {
+		log.Debugw("Notified systemd", "state", state)
+	}
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/build"
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+var log = logging.Logger("fx/tracing")
+
+// Module configures the global OpenTelemetry tracer provider with the
+// configured exporter, and flushes pending spans on shutdown.
+var Module = fx.Module("tracing",
+	fx.Invoke(SetupTracing),
+)
+
+// SetupTracing installs a tracer provider exporting spans as configured. When
+// no exporter is configured, tracing stays disabled.
+func SetupTracing(cfg app.TracingConfig, lc fx.Lifecycle) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Exporter == "none" {
+		return nil
+	}
+
+	exporter, closeExporter, err := newExporter(cfg)
+	if err != nil {
+		return fmt.Errorf("creating %s span exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("padron"),
+		semconv.ServiceVersion(build.Version),
+	))
+	if err != nil {
+		return fmt.Errorf("creating tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	log.Infof("Exporting traces with the %s exporter", cfg.Exporter)
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			if err := tp.Shutdown(ctx); err != nil {
+				return fmt.Errorf("shutting down tracer provider: %w", err)
+			}
+			return closeExporter()
+		},
+	})
+
+	return nil
+}
+
+func newExporter(cfg app.TracingConfig) (sdktrace.SpanExporter, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Exporter {
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exp, noop, err
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening traces file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exp, f.Close, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exp, err := otlptracehttp.New(context.Background(), opts...)
+		return exp, noop, err
+	default:
+		return nil, nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+}
@@ -1,15 +1,26 @@
 package ucan
 
 import (
-	"github.com/alanshaw/ucantone/server"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
+	mh "github.com/multiformats/go-multihash"
+	ucantorequest "github.com/storacha/go-ucanto/transport/car/request"
 	"go.uber.org/fx"
 
 	"github.com/volmedo/padron/pkg/config/app"
 	"github.com/volmedo/padron/pkg/fx/blob"
 	echofx "github.com/volmedo/padron/pkg/fx/echo"
+	ratelimitfx "github.com/volmedo/padron/pkg/fx/ratelimit"
+	ucantofx "github.com/volmedo/padron/pkg/fx/ucanto"
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
 	"github.com/volmedo/padron/pkg/ucan"
+	"github.com/volmedo/padron/pkg/ucan/manifest"
+	"github.com/volmedo/padron/pkg/ucan/server"
 )
 
 var log = logging.Logger("fx/ucan")
@@ -17,7 +28,11 @@ var log = logging.Logger("fx/ucan")
 var _ echofx.RouteRegistrar = (*Server)(nil)
 
 type Server struct {
-	ucanServer *server.HTTPServer
+	ucanServer   *server.HTTPServer
+	ucantoServer *ucantofx.Server
+	manifest     []byte
+	bodyLimit    int64
+	ipLimit      echo.MiddlewareFunc
 }
 
 var Module = fx.Module("ucan/server",
@@ -30,25 +45,106 @@ var Module = fx.Module("ucan/server",
 		),
 	),
 	blob.Module,
+	ucantofx.Module,
 )
 
 type Params struct {
 	fx.In
 	Identity app.IdentityConfig
+	UCAN     app.UCANConfig
+	Blob     app.BlobConfig
+	HTTP     app.HTTPConfig
+	Limiters *ratelimitfx.Limiters
 	Handlers []*ucan.Handler     `group:"ucan_handlers"`
 	Options  []server.HTTPOption `group:"ucan_options"`
+	Legacy   *ucantofx.Server
 }
 
 func NewServer(p Params) (*Server, error) {
-	ucanSvr := server.NewHTTP(p.Identity.Signer, p.Options...)
+	opts := append([]server.HTTPOption{
+		server.WithDispatcherOptions(
+			server.WithClock(p.UCAN.Now),
+			server.WithClockSkew(p.UCAN.ClockSkew),
+		),
+		server.WithDispatcherOptions(audiences(p.Identity)...),
+		server.WithLimiter(p.Limiters.DID),
+	}, p.Options...)
+	ucanSvr := server.NewHTTP(p.Identity.Signer, opts...)
 	log.Infof("Registering %d UCAN handlers", len(p.Handlers))
 	for _, h := range p.Handlers {
 		log.Infof("Registering %q UCAN handler", h.Capability.Command())
 		ucanSvr.Handle(h.Capability, h.Handler)
 	}
-	return &Server{ucanSvr}, nil
+
+	m, err := NewManifest(p)
+	if err != nil {
+		return nil, err
+	}
+	// requests are limited per client IP before they are decoded and
+	// validated, and UCAN 1.0 invocations per issuer once it is verified
+	ipLimit := echofx.RateLimit(p.Limiters.IP, echofx.ClientIP(p.Limiters.TrustForwarded))
+	return &Server{ucanSvr, p.Legacy, m, p.HTTP.UCANBodyLimit, ipLimit}, nil
+}
+
+// audiences accepts invocations addressed to the key of a did:web node, and
+// to retired keys until they expire.
+func audiences(id app.IdentityConfig) []server.DispatcherOption {
+	var opts []server.DispatcherOption
+	for _, a := range id.Audiences() {
+		if !a.ValidUntil.IsZero() {
+			log.Infow("Accepting invocations addressed to retired key", "key", a.Verifier.DID(), "until", a.ValidUntil)
+		}
+		opts = append(opts, server.WithAudience(a.Verifier, a.ValidUntil))
+	}
+	return opts
+}
+
+// NewManifest creates the signed capability manifest of the node, listing the
+// registered UCAN handlers, the legacy ucanto abilities and the blob policies.
+func NewManifest(p Params) ([]byte, error) {
+	codes := make([]string, 0, len(blobsvc.SupportedDigests))
+	for _, code := range blobsvc.SupportedDigests {
+		codes = append(codes, mh.Codes[code])
+	}
+
+	m, err := manifest.New(p.Identity.Signer, p.Handlers, manifest.Policies{
+		MaxBlobSize:    blobsvc.MaxUploadSize,
+		AllocationTTL:  int64(blobsvc.AllocationTTL.Seconds()),
+		CommitmentTTL:  int64(p.Blob.CommitmentTTL.Seconds()),
+		MultihashCodes: codes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating capability manifest: %w", err)
+	}
+	m.LegacyCommands = p.Legacy.Abilities()
+
+	signed, err := manifest.Sign(p.Identity.Signer, m)
+	if err != nil {
+		return nil, fmt.Errorf("signing capability manifest: %w", err)
+	}
+	return signed, nil
 }
 
 func (s *Server) RegisterRoutes(e *echo.Echo) {
-	e.POST("/", echo.WrapHandler(s.ucanServer))
+	ucanHandler := echo.WrapHandler(s.ucanServer)
+	ucantoHandler := echo.WrapHandler(s.ucantoServer)
+	// UCAN 1.0 and legacy ucanto invocations share the endpoint, they are told
+	// apart by the content type of the request.
+	e.POST("/", func(c echo.Context) error {
+		if isUcanto(c.Request()) {
+			return ucantoHandler(c)
+		}
+		return ucanHandler(c)
+	}, s.ipLimit, echofx.BodyLimit(s.bodyLimit))
+	e.GET("/.well-known/ucan", func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+		return c.Blob(http.StatusOK, dagcbor.ContentType, s.manifest)
+	})
+}
+
+// isUcanto reports whether the request carries a legacy ucanto message, by the
+// media type of its content, ignoring any parameters.
+func isUcanto(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == ucantorequest.ContentType
 }
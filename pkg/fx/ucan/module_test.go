@@ -0,0 +1,23 @@
+package ucan
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	ucantorequest "github.com/storacha/go-ucanto/transport/car/request"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUcanto(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		ucantorequest.ContentType:                 true,
+		ucantorequest.ContentType + "; version=1": true,
+		"application/vnd.ipld.dag-cbor":           false,
+		"":                                        false,
+		ucantorequest.ContentType + "; broken=\"": false,
+	} {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set("Content-Type", contentType)
+		require.Equal(t, want, isUcanto(r), contentType)
+	}
+}
@@ -0,0 +1,125 @@
+package ucanto
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/server"
+	ucanhttp "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/validator"
+	"go.uber.org/fx"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	ratelimitfx "github.com/volmedo/padron/pkg/fx/ratelimit"
+	"github.com/volmedo/padron/pkg/ucanto"
+)
+
+var log = logging.Logger("fx/ucanto")
+
+// Module provides a legacy ucanto (UCAN 0.x) server. Service methods are
+// collected from the "ucanto_methods" group.
+var Module = fx.Module("ucanto/server",
+	fx.Provide(NewServer),
+)
+
+var _ http.Handler = (*Server)(nil)
+
+type Server struct {
+	ucantoServer server.ServerView[server.Service]
+	limiter      ucanto.Limiter
+}
+
+type Params struct {
+	fx.In
+	Identity app.IdentityConfig
+	UCAN     app.UCANConfig
+	Limiters *ratelimitfx.Limiters
+	Methods  []server.Option `group:"ucanto_methods"`
+}
+
+func NewServer(p Params) (*Server, error) {
+	id, err := ucanto.ToSigner(p.Identity.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("converting identity to ucanto signer: %w", err)
+	}
+
+	// accept the same audiences as the UCAN 1.0 server: the key of a did:web
+	// node, and retired keys until they expire
+	var (
+		audiences []ucanto.Audience
+		alts      []ucan.Principal
+	)
+	for _, a := range p.Identity.Audiences() {
+		d, err := did.Parse(a.Verifier.DID().String())
+		if err != nil {
+			return nil, fmt.Errorf("parsing audience DID: %w", err)
+		}
+		audiences = append(audiences, ucanto.Audience{DID: d, ValidUntil: a.ValidUntil})
+		alts = append(alts, d)
+	}
+
+	log.Infof("Registering %d legacy ucanto service methods", len(p.Methods))
+	opts := append([]server.Option{
+		server.WithTimeBoundsValidator(NewTimeBoundsValidator(p.UCAN)),
+		server.WithAlternativeAudiences(alts...),
+	}, p.Methods...)
+
+	ucantoSvr, err := server.NewServer(id, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating ucanto server: %w", err)
+	}
+	return &Server{
+		ucantoServer: ucanto.ExpireAudiences(ucantoSvr, p.UCAN.Now, audiences...),
+		limiter:      p.Limiters.DID,
+	}, nil
+}
+
+// Abilities returns the abilities of the legacy service methods, sorted.
+func (s *Server) Abilities() []string {
+	abilities := slices.Collect(maps.Keys(s.ucantoServer.Service()))
+	slices.Sort(abilities)
+	return abilities
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res, err := ucanto.Handle(r.Context(), s.ucantoServer, ucanhttp.NewRequest(r.Body, r.Header), ucanto.WithLimiter(s.limiter))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("handling ucanto request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for k, vv := range res.Headers() {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.Status())
+	io.Copy(w, res.Body())
+	res.Body().Close()
+}
+
+// NewTimeBoundsValidator creates a ucanto time bounds validator that honours
+// the configured clock and clock skew, like the UCAN 1.0 server does.
+func NewTimeBoundsValidator(cfg app.UCANConfig) validator.TimeBoundsValidatorFunc {
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	return func(dlg delegation.Delegation) validator.InvalidProof {
+		t := now()
+		if exp := dlg.Expiration(); exp != nil && int64(*exp) <= t.Add(-cfg.ClockSkew).Unix() {
+			return validator.NewExpiredError(dlg)
+		}
+		if nbf := dlg.NotBefore(); nbf != 0 && t.Add(cfg.ClockSkew).Unix() <= int64(nbf) {
+			return validator.NewNotValidBeforeError(dlg)
+		}
+		return nil
+	}
+}
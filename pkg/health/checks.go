@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alanshaw/ucantone/principal"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/internal/disk"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+// probeDigest is the digest looked up by store checks. It is not expected to
+// be held by the node, the lookup only exercises the store.
+var probeDigest = func() mh.Multihash {
+	digest, err := mh.Sum([]byte("padron/health"), mh.SHA2_256, -1)
+	if err != nil {
+		panic(err)
+	}
+	return digest
+}()
+
+// probe treats a missing record as success, since it means the store was
+// reachable.
+func probe(err error) error {
+	if err == nil || errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// AllocationStoreCheck checks that allocations can be read.
+func AllocationStoreCheck(s allocationstore.AllocationStore) Check {
+	return func(ctx context.Context) error {
+		_, err := s.List(ctx, probeDigest)
+		return probe(err)
+	}
+}
+
+// AcceptanceStoreCheck checks that acceptances can be read.
+func AcceptanceStoreCheck(s acceptancestore.AcceptanceStore) Check {
+	return func(ctx context.Context) error {
+		_, err := s.List(ctx, probeDigest)
+		return probe(err)
+	}
+}
+
+// CommitmentStoreCheck checks that location commitments can be read.
+func CommitmentStoreCheck(s commitmentstore.CommitmentStore, id principal.Signer) Check {
+	return func(ctx context.Context) error {
+		_, err := s.Get(ctx, probeDigest, id.DID())
+		return probe(err)
+	}
+}
+
+// BlobStoreCheck checks that blobs can be read.
+func BlobStoreCheck(s blobstore.Blobstore) Check {
+	return func(ctx context.Context) error {
+		_, err := s.Get(ctx, probeDigest)
+		return probe(err)
+	}
+}
+
+// WritableDirCheck checks that files can be created in the given directory.
+func WritableDirCheck(dir string) Check {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return fmt.Errorf("directory %s is not writable: %w", dir, err)
+		}
+		name := f.Name()
+		if err := f.Close(); err != nil {
+			os.Remove(name)
+			return fmt.Errorf("closing probe file in %s: %w", dir, err)
+		}
+		return os.Remove(name)
+	}
+}
+
+// CapacityCheck checks that the filesystem holding the given directory has at
+// least minFree bytes available.
+func CapacityCheck(dir string, minFree uint64) Check {
+	return func(ctx context.Context) error {
+		_, free, err := disk.Usage(dir)
+		if err != nil {
+			return fmt.Errorf("reading filesystem capacity: %w", err)
+		}
+		if free < minFree {
+			return fmt.Errorf("%d bytes available, %d required", free, minFree)
+		}
+		return nil
+	}
+}
+
+// IdentityCheck checks that the node identity can sign, and that signatures
+// verify against its public key.
+func IdentityCheck(id principal.Signer) Check {
+	return func(ctx context.Context) error {
+		if id == nil {
+			return errors.New("no identity configured")
+		}
+		msg := []byte("padron/health")
+		if !id.Verifier().Verify(msg, id.Sign(msg)) {
+			return fmt.Errorf("signature by %s does not verify", id.DID())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+// Package health provides liveness and readiness endpoints for the node.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("health")
+
+// DefaultCheckTimeout is the time readiness checks are given to complete.
+const DefaultCheckTimeout = 5 * time.Second
+
+// Check reports whether a dependency of the node is usable. It returns a
+// non-nil error when it is not.
+type Check func(ctx context.Context) error
+
+// Status is the outcome of a health request.
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Checker runs readiness checks and tracks whether the node is draining.
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// Option configures a [Checker].
+type Option func(*Checker)
+
+// WithCheck adds a named readiness check.
+func WithCheck(name string, check Check) Option {
+	return func(c *Checker) {
+		if _, ok := c.checks[name]; !ok {
+			c.names = append(c.names, name)
+		}
+		c.checks[name] = check
+	}
+}
+
+// WithTimeout sets the time readiness checks are given to complete.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
+
+// NewChecker creates a readiness checker running the given checks.
+func NewChecker(options ...Option) *Checker {
+	c := &Checker{
+		timeout: DefaultCheckTimeout,
+		checks:  map[string]Check{},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Drain marks the node as shutting down. From then on the node reports itself
+// as not ready, so that orchestrators stop routing requests to it.
+func (c *Checker) Drain() {
+	if !c.draining.Swap(true) {
+		log.Info("Draining, readiness checks will now fail")
+	}
+}
+
+// Draining reports whether the node is shutting down.
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Ready runs all the checks concurrently and reports their outcome by name.
+// The node is ready when it is not draining and all checks pass.
+func (c *Checker) Ready(ctx context.Context) (bool, map[string]error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(c.names))
+	)
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			err := check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	ready := !c.Draining()
+	for _, err := range results {
+		if err != nil {
+			ready = false
+		}
+	}
+	return ready, results
+}
+
+// LivenessHandler reports that the process is up and serving requests.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, Status{Status: "ok"})
+	})
+}
+
+// ReadinessHandler reports whether the node is able to accept uploads. It
+// responds with 503 Service Unavailable when any check fails or the node is
+// draining.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ready, results := c.Ready(r.Context())
+
+		status := Status{Status: "ok", Checks: make(map[string]string, len(results))}
+		for name, err := range results {
+			if err != nil {
+				log.Warnw("readiness check failed", "check", name, "error", err)
+				status.Checks[name] = err.Error()
+			} else {
+				status.Checks[name] = "ok"
+			}
+		}
+
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+			status.Status = "unavailable"
+		}
+		if c.Draining() {
+			status.Status = "draining"
+		}
+		writeStatus(w, code, status)
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Errorf("failed to write health status: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/health"
+)
+
+func getStatus(t *testing.T, h http.Handler, path string) (int, health.Status) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var status health.Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return rec.Code, status
+}
+
+func TestLiveness(t *testing.T) {
+	code, status := getStatus(t, health.LivenessHandler(), "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", status.Status)
+}
+
+func TestReadiness(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("boom") }
+
+	t.Run("ready", func(t *testing.T) {
+		checker := health.NewChecker(health.WithCheck("store", ok))
+
+		code, status := getStatus(t, checker.ReadinessHandler(), "/readyz")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "ok", status.Status)
+		require.Equal(t, "ok", status.Checks["store"])
+	})
+
+	t.Run("failing check", func(t *testing.T) {
+		checker := health.NewChecker(
+			health.WithCheck("store", ok),
+			health.WithCheck("capacity", failing),
+		)
+
+		code, status := getStatus(t, checker.ReadinessHandler(), "/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "unavailable", status.Status)
+		require.Equal(t, "ok", status.Checks["store"])
+		require.Equal(t, "boom", status.Checks["capacity"])
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		checker := health.NewChecker(health.WithCheck("store", ok))
+		checker.Drain()
+
+		code, status := getStatus(t, checker.ReadinessHandler(), "/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "draining", status.Status)
+	})
+
+	t.Run("writable dir", func(t *testing.T) {
+		check := health.WritableDirCheck(t.TempDir())
+		require.NoError(t, check(context.Background()))
+
+		check = health.WritableDirCheck("/nonexistent/padron")
+		require.Error(t, check(context.Background()))
+	})
+}
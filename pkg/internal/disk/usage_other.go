@@ -0,0 +1,12 @@
+//go:build !unix
+
+// Package disk reports the usage of the filesystems holding node data.
+package disk
+
+import "errors"
+
+// Usage returns the total and available bytes of the filesystem holding the
+// given path.
+func Usage(path string) (total uint64, free uint64, err error) {
+	return 0, 0, errors.New("filesystem capacity is not supported on this platform")
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+// Package disk reports the usage of the filesystems holding node data.
+package disk
+
+import "golang.org/x/sys/unix"
+
+// Usage returns the total and available bytes of the filesystem holding the
+// given path.
+func Usage(path string) (total uint64, free uint64, err error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Blocks * uint64(st.Bsize), st.Bavail * uint64(st.Bsize), nil
+}
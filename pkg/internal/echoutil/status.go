@@ -0,0 +1,22 @@
+// Package echoutil provides helpers shared by echo middlewares.
+package echoutil
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ResponseStatus returns the status code of the response, accounting for
+// errors returned by a handler that have not been written to the response yet.
+func ResponseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,117 @@
+package keyagent_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/keyagent"
+)
+
+func TestSigner(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := keyagent.Listen(socket)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- keyagent.NewServer(id).Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	signer, err := keyagent.Dial(socket)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	require.Equal(t, id.DID(), signer.DID())
+	require.Nil(t, signer.Raw())
+
+	msg := []byte("testing 1, 2, 3")
+	sig := signer.Sign(msg)
+	require.Equal(t, id.Sign(msg), sig)
+	require.True(t, id.Verifier().Verify(msg, sig))
+
+	t.Run("reconnects after the connection drops", func(t *testing.T) {
+		require.NoError(t, signer.Close())
+		require.True(t, id.Verifier().Verify(msg, signer.Sign(msg)))
+	})
+
+	t.Run("reports signing failures", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		l, err := keyagent.Listen(socket)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- keyagent.NewServer(id).Serve(ctx, l) }()
+
+		other, err := keyagent.Dial(socket)
+		require.NoError(t, err)
+		defer other.Close()
+
+		// the agent goes away
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, os.Remove(socket))
+
+		_, err = other.TrySign(msg)
+		require.Error(t, err)
+		require.Nil(t, other.Sign(msg))
+	})
+}
+
+func TestListen(t *testing.T) {
+	t.Run("only the owner can use the socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		l, err := keyagent.Listen(socket)
+		require.NoError(t, err)
+		defer l.Close()
+
+		fi, err := os.Stat(socket)
+		require.NoError(t, err)
+		require.Equal(t, keyagent.DefaultSocketMode, fi.Mode().Perm())
+		require.NotZero(t, fi.Mode()&os.ModeSocket)
+
+		// only the socket is left in its directory
+		entries, err := os.ReadDir(filepath.Dir(socket))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("sets the configured mode and group", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		l, err := keyagent.Listen(socket, keyagent.WithMode(0o660), keyagent.WithGroup(os.Getgid()))
+		require.NoError(t, err)
+		defer l.Close()
+
+		fi, err := os.Stat(socket)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+		require.Equal(t, uint32(os.Getgid()), fi.Sys().(*syscall.Stat_t).Gid)
+	})
+
+	t.Run("replaces a stale socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		stale, err := keyagent.Listen(socket)
+		require.NoError(t, err)
+		stale.Close()
+
+		l, err := keyagent.Listen(socket)
+		require.NoError(t, err)
+		defer l.Close()
+
+		conn, err := net.Dial("unix", socket)
+		require.NoError(t, err)
+		conn.Close()
+	})
+}
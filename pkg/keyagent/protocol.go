@@ -0,0 +1,31 @@
+// Package keyagent keeps the node private key out of the network-facing
+// process. A key agent holds the key and signs on request over a Unix socket;
+// the node uses a [Signer] that forwards every signature to the agent.
+//
+// The protocol is a sequence of newline-delimited JSON requests, each
+// answered by a single response, over a connection to the agent socket.
+package keyagent
+
+const (
+	// OpDescribe asks the agent for the DID and public key of its signer.
+	OpDescribe = "describe"
+	// OpSign asks the agent to sign a message.
+	OpSign = "sign"
+)
+
+// Request is a request to the agent.
+type Request struct {
+	Op string `json:"op"`
+	// Message is the message to sign, for [OpSign].
+	Message []byte `json:"message,omitempty"`
+}
+
+// Response is the answer to a [Request]. Error is set if the request failed.
+type Response struct {
+	Error string `json:"error,omitempty"`
+	// DID and PublicKey answer [OpDescribe].
+	DID       string `json:"did,omitempty"`
+	PublicKey []byte `json:"publicKey,omitempty"`
+	// Signature answers [OpSign].
+	Signature []byte `json:"signature,omitempty"`
+}
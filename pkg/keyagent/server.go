@@ -0,0 +1,185 @@
+package keyagent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/alanshaw/ucantone/principal"
+)
+
+// MaxMessageSize is the largest request the agent reads. Signed payloads are
+// UCAN envelopes and receipts, well below this size.
+const MaxMessageSize = 1 << 20
+
+// Server is a reference key agent, signing with a key it holds in memory.
+type Server struct {
+	signer principal.Signer
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewServer creates an agent that signs with the given signer.
+func NewServer(signer principal.Signer) *Server {
+	return &Server{signer: signer, conns: map[net.Conn]struct{}{}}
+}
+
+// DefaultSocketMode makes the agent socket readable and writable only by its
+// owner.
+const DefaultSocketMode os.FileMode = 0o600
+
+type listenConfig struct {
+	mode  os.FileMode
+	group int
+}
+
+// ListenOption configures the agent socket created by [Listen].
+type ListenOption func(*listenConfig)
+
+// WithMode sets the permissions of the socket. Defaults to
+// [DefaultSocketMode].
+func WithMode(mode os.FileMode) ListenOption {
+	return func(c *listenConfig) {
+		c.mode = mode
+	}
+}
+
+// WithGroup sets the group owning the socket, so the node can run as another
+// user in that group when the mode grants the group access.
+func WithGroup(gid int) ListenOption {
+	return func(c *listenConfig) {
+		c.group = gid
+	}
+}
+
+// Listen creates the agent socket, readable and writable only by its owner
+// unless configured otherwise. A stale socket file left by a previous agent is
+// removed. The socket is not removed when the listener is closed.
+func Listen(socket string, options ...ListenOption) (net.Listener, error) {
+	cfg := listenConfig{mode: DefaultSocketMode, group: -1}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	if fi, err := os.Lstat(socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	// the socket is bound in a directory only accessible to its owner, and
+	// moved into place once its permissions are set, so it is never reachable
+	// with the default ones
+	dir, err := os.MkdirTemp(filepath.Dir(socket), ".keyagent-")
+	if err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "agent.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	l.SetUnlinkOnClose(false)
+	if err := setPermissions(tmp, cfg); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, socket); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("moving socket into place: %w", err)
+	}
+	return l, nil
+}
+
+func setPermissions(socket string, cfg listenConfig) error {
+	if cfg.group >= 0 {
+		if err := os.Chown(socket, -1, cfg.group); err != nil {
+			return fmt.Errorf("setting socket group: %w", err)
+		}
+	}
+	if err := os.Chmod(socket, cfg.mode); err != nil {
+		return fmt.Errorf("setting socket permissions: %w", err)
+	}
+	return nil
+}
+
+// Serve accepts connections on the listener until the context is done.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			log.Warnw("accepting key agent connection", "error", err)
+			continue
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxMessageSize)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			enc.Encode(Response{Error: fmt.Sprintf("decoding request: %s", err)})
+			return
+		}
+		if err := enc.Encode(s.handle(req)); err != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warnw("reading key agent request", "error", err)
+	}
+}
+
+func (s *Server) handle(req Request) Response {
+	switch req.Op {
+	case OpDescribe:
+		return Response{
+			DID:       s.signer.DID().String(),
+			PublicKey: s.signer.Verifier().Raw(),
+		}
+	case OpSign:
+		log.Debugw("signing", "size", len(req.Message))
+		return Response{Signature: s.signer.Sign(req.Message)}
+	default:
+		return Response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
@@ -0,0 +1,196 @@
+package keyagent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/principal/ed25519/verifier"
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("keyagent")
+
+// ErrInvalidSignature is returned when the agent signs with a key other than
+// the one it described.
+var ErrInvalidSignature = errors.New("key agent returned an invalid signature")
+
+// DefaultTimeout bounds each request to the agent.
+const DefaultTimeout = 5 * time.Second
+
+// Signer is an ed25519 signer whose private key is held by a key agent.
+//
+// The private key is never available, so Bytes and Raw return nil. Sign has
+// no way to report errors, so a failed request is logged and yields a nil
+// signature. Use TrySign to get the error, or check the tokens it signs with
+// CheckSigned from pkg/ucan.
+type Signer struct {
+	socket   string
+	timeout  time.Duration
+	verifier principal.Verifier
+
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+var _ principal.Signer = (*Signer)(nil)
+
+// Option configures a [Signer].
+type Option func(*Signer)
+
+// WithTimeout sets the timeout of each request to the agent.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Signer) {
+		s.timeout = timeout
+	}
+}
+
+// Dial connects to the agent listening on the socket and fetches the public
+// key of its signer.
+func Dial(socket string, options ...Option) (*Signer, error) {
+	s := &Signer{socket: socket, timeout: DefaultTimeout}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	res, err := s.do(Request{Op: OpDescribe})
+	if err != nil {
+		return nil, fmt.Errorf("describing agent signer: %w", err)
+	}
+	v, err := verifier.FromRaw(res.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent public key: %w", err)
+	}
+	if res.DID != v.DID().String() {
+		return nil, fmt.Errorf("agent DID %s does not match its public key %s", res.DID, v.DID())
+	}
+	s.verifier = v
+	return s, nil
+}
+
+func (s *Signer) Code() uint64 {
+	return ed25519.Code
+}
+
+func (s *Signer) SignatureCode() uint64 {
+	return ed25519.SignatureCode
+}
+
+func (s *Signer) Verifier() principal.Verifier {
+	return s.verifier
+}
+
+func (s *Signer) DID() did.DID {
+	return s.verifier.DID()
+}
+
+// Bytes returns nil, the private key is only known to the agent.
+func (s *Signer) Bytes() []byte {
+	return nil
+}
+
+// Raw returns nil, the private key is only known to the agent.
+func (s *Signer) Raw() []byte {
+	return nil
+}
+
+// Sign signs the message with the agent key, returning nil if it fails.
+func (s *Signer) Sign(msg []byte) []byte {
+	sig, err := s.TrySign(msg)
+	if err != nil {
+		log.Errorw("signing with key agent", "socket", s.socket, "error", err)
+		return nil
+	}
+	return sig
+}
+
+// TrySign signs the message with the agent key, returning an error if the
+// agent cannot be reached or its signature is invalid.
+func (s *Signer) TrySign(msg []byte) ([]byte, error) {
+	res, err := s.do(Request{Op: OpSign, Message: msg})
+	if err != nil {
+		return nil, err
+	}
+	if !s.verifier.Verify(msg, res.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	return res.Signature, nil
+}
+
+// Close closes the connection to the agent.
+func (s *Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reset()
+}
+
+// do sends a request over the shared connection, dialing the agent if needed.
+// A request failing on a connection that was already open is retried once on
+// a new connection, in case the agent restarted.
+func (s *Signer) do(req Request) (Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reused := s.conn != nil
+	res, err := s.roundTrip(req)
+	if err != nil && reused {
+		res, err = s.roundTrip(req)
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	if res.Error != "" {
+		return Response{}, errors.New(res.Error)
+	}
+	return res, nil
+}
+
+func (s *Signer) roundTrip(req Request) (Response, error) {
+	if s.conn == nil {
+		conn, err := net.DialTimeout("unix", s.socket, s.timeout)
+		if err != nil {
+			return Response{}, fmt.Errorf("connecting to key agent: %w", err)
+		}
+		s.conn = conn
+		s.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	}
+
+	var res Response
+	err := s.conn.SetDeadline(time.Now().Add(s.timeout))
+	if err == nil {
+		err = json.NewEncoder(s.rw).Encode(req)
+	}
+	if err == nil {
+		err = s.rw.Flush()
+	}
+	if err == nil {
+		var line []byte
+		line, err = s.rw.ReadBytes('\n')
+		if err == nil {
+			err = json.Unmarshal(line, &res)
+		}
+	}
+	if err != nil {
+		s.reset()
+		return Response{}, fmt.Errorf("key agent request: %w", err)
+	}
+	return res, nil
+}
+
+func (s *Signer) reset() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	s.rw = nil
+	return err
+}
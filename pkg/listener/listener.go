@@ -0,0 +1,182 @@
+// Package listener opens the sockets the node serves on: TCP addresses, Unix
+// sockets, and sockets passed by systemd socket activation.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/activation"
+)
+
+// Address forms accepted by [Listen].
+const (
+	// UnixPrefix starts the path of a Unix socket, e.g. unix:/run/padron.sock.
+	UnixPrefix = "unix:"
+	// SystemdPrefix selects sockets passed by systemd. "systemd" alone selects
+	// all of them, "systemd:name" those with the given FileDescriptorName.
+	SystemdPrefix = "systemd"
+)
+
+// Option configures [Listen].
+type Option func(*options)
+
+type options struct {
+	socketMode fs.FileMode
+}
+
+// WithUnixSocketMode sets the permissions of the Unix sockets created by
+// [Listen], e.g. 0o660 to let a reverse proxy in the group of the node
+// connect. Sockets are bound in a private directory and moved into place once
+// their mode is set, so they are never reachable with wider permissions. By
+// default sockets are created with the permissions allowed by the umask.
+func WithUnixSocketMode(mode fs.FileMode) Option {
+	return func(o *options) {
+		o.socketMode = mode
+	}
+}
+
+// Listen opens a listener for every address, which is either a TCP
+// host:port, a Unix socket path prefixed with "unix:", or a selection of the
+// sockets passed by systemd. If any of them cannot be opened, the ones already
+// opened are closed and an error is returned.
+func Listen(addrs []string, opts ...Option) ([]net.Listener, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var (
+		listeners []net.Listener
+		activated map[string][]net.Listener
+	)
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		switch {
+		case addr == SystemdPrefix || strings.HasPrefix(addr, SystemdPrefix+":"):
+			if activated == nil {
+				var err error
+				if activated, err = activation.ListenersWithNames(); err != nil {
+					return fail(fmt.Errorf("getting systemd sockets: %w", err))
+				}
+			}
+			selected, err := systemdListeners(activated, strings.TrimPrefix(strings.TrimPrefix(addr, SystemdPrefix), ":"))
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, selected...)
+		case strings.HasPrefix(addr, UnixPrefix):
+			l, err := listenUnix(strings.TrimPrefix(strings.TrimPrefix(addr, UnixPrefix), "//"), o.socketMode)
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, l)
+		default:
+			l, err := net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+			if err != nil {
+				return fail(fmt.Errorf("listening on %s: %w", addr, err))
+			}
+			listeners = append(listeners, l)
+		}
+	}
+	return listeners, nil
+}
+
+// systemdListeners takes the sockets passed by systemd with the given name, or
+// all of them if name is empty. Taken sockets are removed from activated, so
+// each is served once.
+func systemdListeners(activated map[string][]net.Listener, name string) ([]net.Listener, error) {
+	var selected []net.Listener
+	for n, ls := range activated {
+		if name == "" || n == name {
+			selected = append(selected, ls...)
+			delete(activated, n)
+		}
+	}
+	if len(selected) == 0 {
+		if name == "" {
+			return nil, errors.New("no sockets passed by systemd")
+		}
+		return nil, fmt.Errorf("no socket named %q passed by systemd", name)
+	}
+	return selected, nil
+}
+
+// listenUnix listens on a Unix socket, replacing a stale socket file left by a
+// previous run, with the given mode if not zero. The socket file is removed
+// when the listener is closed.
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("listening on %s: socket in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket %s: %w", path, err)
+		}
+	}
+	if mode == 0 {
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("listening on %s: %w", path, err)
+		}
+		return l, nil
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".padron-")
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", path, err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, filepath.Base(path))
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", path, err)
+	}
+	// the socket file is renamed, it is removed by unixListener instead
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("setting mode of socket %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("listening on %s: %w", path, err)
+	}
+	return &unixListener{Listener: l, path: path}, nil
+}
+
+// unixListener is a Unix socket bound at a temporary path and moved to path.
+type unixListener struct {
+	net.Listener
+	path string
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// String formats the address of a listener as accepted by [Listen].
+func String(l net.Listener) string {
+	if l.Addr().Network() == "unix" {
+		return UnixPrefix + l.Addr().String()
+	}
+	return l.Addr().String()
+}
@@ -0,0 +1,103 @@
+package listener_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/listener"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("tcp and unix", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "padron.sock")
+		listeners, err := listener.Listen([]string{"127.0.0.1:0", "unix:" + sock})
+		require.NoError(t, err)
+		require.Len(t, listeners, 2)
+		defer func() {
+			for _, l := range listeners {
+				l.Close()
+			}
+		}()
+
+		require.Equal(t, "tcp", listeners[0].Addr().Network())
+		require.Equal(t, "unix:"+sock, listener.String(listeners[1]))
+
+		conn, err := net.Dial("unix", sock)
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("unix socket mode", func(t *testing.T) {
+		dir := t.TempDir()
+		sock := filepath.Join(dir, "padron.sock")
+		listeners, err := listener.Listen([]string{"unix:" + sock}, listener.WithUnixSocketMode(0o660))
+		require.NoError(t, err)
+		require.Equal(t, "unix:"+sock, listener.String(listeners[0]))
+
+		info, err := os.Stat(sock)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+		conn, err := net.Dial("unix", sock)
+		require.NoError(t, err)
+		conn.Close()
+
+		// the private directory the socket was bound in is gone
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		require.NoError(t, listeners[0].Close())
+		_, err = os.Stat(sock)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("stale unix socket", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "padron.sock")
+		l, err := net.Listen("unix", sock)
+		require.NoError(t, err)
+		// leave the socket file behind, as a crashed process would
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, l.Close())
+
+		listeners, err := listener.Listen([]string{"unix://" + sock})
+		require.NoError(t, err)
+		require.NoError(t, listeners[0].Close())
+	})
+
+	t.Run("unix socket in use", func(t *testing.T) {
+		sock := filepath.Join(t.TempDir(), "padron.sock")
+		l, err := net.Listen("unix", sock)
+		require.NoError(t, err)
+		defer l.Close()
+
+		_, err = listener.Listen([]string{"unix:" + sock})
+		require.ErrorContains(t, err, "in use")
+	})
+
+	t.Run("fails and closes opened listeners", func(t *testing.T) {
+		taken, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer taken.Close()
+
+		sock := filepath.Join(t.TempDir(), "padron.sock")
+		_, err = listener.Listen([]string{"unix:" + sock, taken.Addr().String()})
+		require.Error(t, err)
+
+		// the unix socket was closed, and its file removed
+		_, err = net.Dial("unix", sock)
+		require.Error(t, err)
+	})
+
+	t.Run("no systemd sockets", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "")
+		t.Setenv("LISTEN_FDS", "")
+
+		_, err := listener.Listen([]string{"systemd"})
+		require.ErrorContains(t, err, "no sockets passed by systemd")
+	})
+}
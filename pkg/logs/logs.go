@@ -0,0 +1,131 @@
+// Package logs configures the output, format and levels of the node loggers,
+// and allows them to be changed while the node is running.
+package logs
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/mattn/go-isatty"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+var (
+	mu      sync.Mutex
+	current app.LoggingConfig
+	file    *lumberjack.Logger
+)
+
+// Setup applies the logging configuration. It can be called again at any time
+// to replace the current configuration.
+func Setup(cfg app.LoggingConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	level, err := logging.Parse(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+	subsystems := make(map[string]logging.LogLevel, len(cfg.Subsystems))
+	for name, l := range cfg.Subsystems {
+		subsystems[name], err = logging.Parse(l)
+		if err != nil {
+			return fmt.Errorf("parsing log level of %s: %w", name, err)
+		}
+	}
+
+	var (
+		ws      zapcore.WriteSyncer
+		newFile *lumberjack.Logger
+	)
+	if cfg.File != "" {
+		newFile = &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		}
+		ws = zapcore.AddSync(newFile)
+	} else {
+		ws = zapcore.Lock(os.Stderr)
+	}
+
+	encoder, err := newEncoder(cfg.Format, cfg.File == "" && isatty.IsTerminal(os.Stderr.Fd()))
+	if err != nil {
+		return err
+	}
+
+	// levels are applied per subsystem, the primary core logs everything
+	logging.SetupLogging(logging.Config{
+		Level:           level,
+		SubsystemLevels: subsystems,
+	})
+	logging.SetPrimaryCore(zapcore.NewCore(encoder, ws, zap.NewAtomicLevelAt(zapcore.DebugLevel)))
+
+	if file != nil {
+		file.Close()
+	}
+	file = newFile
+
+	current = cfg
+	current.Subsystems = maps.Clone(cfg.Subsystems)
+	return nil
+}
+
+// Config returns the logging configuration currently applied, including any
+// level changed at runtime.
+func Config() app.LoggingConfig {
+	mu.Lock()
+	defer mu.Unlock()
+
+	cfg := current
+	cfg.Subsystems = maps.Clone(current.Subsystems)
+	return cfg
+}
+
+// SetLevel changes the level of a subsystem at runtime. The subsystem "*"
+// changes the level of all subsystems.
+func SetLevel(subsystem, level string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := logging.SetLogLevel(subsystem, level); err != nil {
+		return err
+	}
+	if subsystem == "*" {
+		current.Level = level
+		current.Subsystems = nil
+		return nil
+	}
+	if current.Subsystems == nil {
+		current.Subsystems = map[string]string{}
+	}
+	current.Subsystems[subsystem] = level
+	return nil
+}
+
+func newEncoder(format string, color bool) (zapcore.Encoder, error) {
+	encCfg := zap.NewProductionEncoderConfig()
+	encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	switch format {
+	case "json":
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case "", "console":
+		encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		if color {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/volmedo/padron/pkg/internal/disk"
+)
+
+// RegisterCapacity registers gauges reporting the total and available capacity
+// of the filesystem holding the given data directory.
+func RegisterCapacity(dir string) error {
+	capacity := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "capacity_bytes",
+		Help:      "Total capacity of the filesystem holding the data directory.",
+	}, func() float64 {
+		total, _, err := disk.Usage(dir)
+		if err != nil {
+			log.Warnw("reading filesystem capacity", "dir", dir, "error", err)
+			return 0
+		}
+		return float64(total)
+	})
+
+	available := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "available_bytes",
+		Help:      "Available capacity of the filesystem holding the data directory.",
+	}, func() float64 {
+		_, free, err := disk.Usage(dir)
+		if err != nil {
+			log.Warnw("reading filesystem capacity", "dir", dir, "error", err)
+			return 0
+		}
+		return float64(free)
+	})
+
+	for _, c := range []prometheus.Collector{capacity, available} {
+		if err := Registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
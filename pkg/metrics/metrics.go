@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/storacha/piri/pkg/store"
+
+	"github.com/volmedo/padron/pkg/internal/echoutil"
+)
+
+var log = logging.Logger("metrics")
+
+const namespace = "padron"
+
+// Registry is the registry all padrón metrics are registered with.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ucanInvocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ucan",
+		Name:      "invocations_total",
+		Help:      "Number of UCAN invocations executed, including legacy ucanto ones, by command and outcome.",
+	}, []string{"command", "outcome"})
+
+	blobBytesUploaded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blob",
+		Name:      "uploaded_bytes_total",
+		Help:      "Number of blob bytes uploaded to the node.",
+	})
+
+	blobBytesServed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blob",
+		Name:      "served_bytes_total",
+		Help:      "Number of blob bytes served by the node.",
+	})
+
+	blobAllocations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blob",
+		Name:      "allocations_total",
+		Help:      "Number of blob allocations made.",
+	})
+
+	blobAcceptances = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blob",
+		Name:      "acceptances_total",
+		Help:      "Number of blobs accepted.",
+	})
+
+	storeOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of store operations, by store, operation and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"store", "operation", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		ucanInvocations,
+		blobBytesUploaded,
+		blobBytesServed,
+		blobAllocations,
+		blobAcceptances,
+		storeOperationDuration,
+	)
+}
+
+// Handler returns an HTTP handler that exposes the metrics in the Prometheus
+// exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware is an echo middleware that records request counts and latencies
+// per route.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			method := c.Request().Method
+			status := strconv.Itoa(echoutil.ResponseStatus(c, err))
+
+			httpRequests.WithLabelValues(method, route, status).Inc()
+			httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// UnknownCommand is the command label for invocations of commands the node
+// does not handle, so arbitrary commands do not create new series.
+const UnknownCommand = "unknown"
+
+// ObserveInvocation records the execution of a UCAN invocation of the given
+// command, which must be one the node handles or [UnknownCommand]. The outcome
+// is "ok" or "error".
+func ObserveInvocation(command string, outcome string) {
+	ucanInvocations.WithLabelValues(command, outcome).Inc()
+}
+
+// AddBytesUploaded records bytes uploaded to the node.
+func AddBytesUploaded(n uint64) {
+	blobBytesUploaded.Add(float64(n))
+}
+
+// AddBytesServed records bytes served by the node.
+func AddBytesServed(n int64) {
+	blobBytesServed.Add(float64(n))
+}
+
+// IncAllocations records a blob allocation.
+func IncAllocations() {
+	blobAllocations.Inc()
+}
+
+// IncAcceptances records a blob acceptance.
+func IncAcceptances() {
+	blobAcceptances.Inc()
+}
+
+func observeStoreOperation(name, operation string, start time.Time, err error) {
+	outcome := "ok"
+	if errors.Is(err, store.ErrNotFound) {
+		outcome = "not_found"
+	} else if err != nil {
+		outcome = "error"
+	}
+	storeOperationDuration.WithLabelValues(name, operation, outcome).Observe(time.Since(start).Seconds())
+}
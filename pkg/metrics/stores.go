@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alanshaw/ucantone/did"
+	mh "github.com/multiformats/go-multihash"
+	ucantodid "github.com/storacha/go-ucanto/did"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+type blobstoreMetrics struct {
+	blobs blobstore.Blobstore
+}
+
+// InstrumentBlobstore wraps a blob store, recording the latency of its
+// operations. Filesystem access is preserved if the wrapped store supports it.
+func InstrumentBlobstore(blobs blobstore.Blobstore) blobstore.Blobstore {
+	if fsblobs, ok := blobs.(blobstore.FileSystemer); ok {
+		return &fsBlobstoreMetrics{blobstoreMetrics{blobs}, fsblobs}
+	}
+	return &blobstoreMetrics{blobs}
+}
+
+func (b *blobstoreMetrics) Get(ctx context.Context, digest mh.Multihash, opts ...blobstore.GetOption) (obj blobstore.Object, err error) {
+	defer func(start time.Time) { observeStoreOperation("blob", "get", start, err) }(time.Now())
+	return b.blobs.Get(ctx, digest, opts...)
+}
+
+func (b *blobstoreMetrics) Put(ctx context.Context, digest mh.Multihash, size uint64, body io.Reader) (err error) {
+	defer func(start time.Time) { observeStoreOperation("blob", "put", start, err) }(time.Now())
+	return b.blobs.Put(ctx, digest, size, body)
+}
+
+type fsBlobstoreMetrics struct {
+	blobstoreMetrics
+	fs blobstore.FileSystemer
+}
+
+func (b *fsBlobstoreMetrics) FileSystem() http.FileSystem {
+	return b.fs.FileSystem()
+}
+
+type allocationStoreMetrics struct {
+	allocs allocationstore.AllocationStore
+}
+
+// InstrumentAllocationStore wraps an allocation store, recording the latency of
+// its operations.
+func InstrumentAllocationStore(allocs allocationstore.AllocationStore) allocationstore.AllocationStore {
+	return &allocationStoreMetrics{allocs}
+}
+
+func (a *allocationStoreMetrics) Get(ctx context.Context, digest mh.Multihash, space ucantodid.DID) (alloc allocation.Allocation, err error) {
+	defer func(start time.Time) { observeStoreOperation("allocation", "get", start, err) }(time.Now())
+	return a.allocs.Get(ctx, digest, space)
+}
+
+func (a *allocationStoreMetrics) List(ctx context.Context, digest mh.Multihash, opts ...allocationstore.ListOption) (allocs []allocation.Allocation, err error) {
+	defer func(start time.Time) { observeStoreOperation("allocation", "list", start, err) }(time.Now())
+	return a.allocs.List(ctx, digest, opts...)
+}
+
+func (a *allocationStoreMetrics) Put(ctx context.Context, alloc allocation.Allocation) (err error) {
+	defer func(start time.Time) { observeStoreOperation("allocation", "put", start, err) }(time.Now())
+	return a.allocs.Put(ctx, alloc)
+}
+
+type acceptanceStoreMetrics struct {
+	acceptances acceptancestore.AcceptanceStore
+}
+
+// InstrumentAcceptanceStore wraps an acceptance store, recording the latency of
+// its operations.
+func InstrumentAcceptanceStore(acceptances acceptancestore.AcceptanceStore) acceptancestore.AcceptanceStore {
+	return &acceptanceStoreMetrics{acceptances}
+}
+
+func (a *acceptanceStoreMetrics) Get(ctx context.Context, digest mh.Multihash, space ucantodid.DID) (acc acceptance.Acceptance, err error) {
+	defer func(start time.Time) { observeStoreOperation("acceptance", "get", start, err) }(time.Now())
+	return a.acceptances.Get(ctx, digest, space)
+}
+
+func (a *acceptanceStoreMetrics) List(ctx context.Context, digest mh.Multihash, opts ...acceptancestore.ListOption) (accs []acceptance.Acceptance, err error) {
+	defer func(start time.Time) { observeStoreOperation("acceptance", "list", start, err) }(time.Now())
+	return a.acceptances.List(ctx, digest, opts...)
+}
+
+func (a *acceptanceStoreMetrics) Put(ctx context.Context, acc acceptance.Acceptance) (err error) {
+	defer func(start time.Time) { observeStoreOperation("acceptance", "put", start, err) }(time.Now())
+	return a.acceptances.Put(ctx, acc)
+}
+
+type commitmentStoreMetrics struct {
+	commitments commitmentstore.CommitmentStore
+}
+
+// InstrumentCommitmentStore wraps a location commitment store, recording the
+// latency of its operations.
+func InstrumentCommitmentStore(commitments commitmentstore.CommitmentStore) commitmentstore.CommitmentStore {
+	return &commitmentStoreMetrics{commitments}
+}
+
+func (c *commitmentStoreMetrics) Get(ctx context.Context, digest mh.Multihash, space did.DID) (cm commitmentstore.Commitment, err error) {
+	defer func(start time.Time) { observeStoreOperation("commitment", "get", start, err) }(time.Now())
+	return c.commitments.Get(ctx, digest, space)
+}
+
+func (c *commitmentStoreMetrics) Put(ctx context.Context, cm commitmentstore.Commitment) (err error) {
+	defer func(start time.Time) { observeStoreOperation("commitment", "put", start, err) }(time.Now())
+	return c.commitments.Put(ctx, cm)
+}
+
+func (c *commitmentStoreMetrics) Delete(ctx context.Context, digest mh.Multihash, space did.DID) (err error) {
+	defer func(start time.Time) { observeStoreOperation("commitment", "delete", start, err) }(time.Now())
+	return c.commitments.Delete(ctx, digest, space)
+}
+
+func (c *commitmentStoreMetrics) ListExpiring(ctx context.Context, before uint64) (cms []commitmentstore.Commitment, err error) {
+	defer func(start time.Time) { observeStoreOperation("commitment", "list_expiring", start, err) }(time.Now())
+	return c.commitments.ListExpiring(ctx, before)
+}
@@ -0,0 +1,142 @@
+// Package ratelimit limits the rate of requests per client, with a token
+// bucket for each client key, such as a DID or an IP address.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval is how often buckets that have refilled are dropped, so
+// clients seen once do not hold memory forever.
+const sweepInterval = time.Minute
+
+// Limiter rate limits requests by key. The zero rate disables it.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	buckets map[string]*rate.Limiter
+	swept   time.Time
+}
+
+// New creates a limiter allowing perSecond requests per second for each key,
+// with bursts of up to burst requests.
+func New(perSecond float64, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Limit(perSecond),
+		burst:   max(burst, 1),
+		buckets: map[string]*rate.Limiter{},
+	}
+}
+
+// SetLimit changes the rate and burst of every key, including those already
+// seen.
+func (l *Limiter) SetLimit(perSecond float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit, l.burst = rate.Limit(perSecond), max(burst, 1)
+	now := time.Now()
+	for _, b := range l.buckets {
+		b.SetLimitAt(now, l.limit)
+		b.SetBurstAt(now, l.burst)
+	}
+}
+
+// LimitError is returned for a key over its limit.
+type LimitError struct {
+	Key string
+	// RetryAfter is how long until the key has enough tokens.
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s", e.Key)
+}
+
+// BurstError is returned for a key repeated more times than the burst, which
+// is never allowed, however long the client waits.
+type BurstError struct {
+	Key   string
+	N     int
+	Burst int
+}
+
+func (e *BurstError) Error() string {
+	return fmt.Sprintf("%d requests for %s exceed the burst of %d", e.N, e.Key, e.Burst)
+}
+
+// Allow takes a token from the bucket of key. When there is none, it returns
+// false and how long until there is one.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	var limitErr *LimitError
+	if errors.As(l.AllowAll([]string{key}), &limitErr) {
+		return false, limitErr.RetryAfter
+	}
+	return true, 0
+}
+
+// AllowAll takes a token from the bucket of every key, as many times as the
+// key is repeated, only if all of them have enough. Otherwise no token is
+// taken, and it returns a [*LimitError] for the first key over its limit, or
+// a [*BurstError] if a key is repeated more times than the burst.
+func (l *Limiter) AllowAll(keys []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(l.swept) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	counts := map[string]int{}
+	for _, key := range keys {
+		counts[key]++
+	}
+	for _, key := range keys {
+		if counts[key] > l.burst {
+			return &BurstError{Key: key, N: counts[key], Burst: l.burst}
+		}
+	}
+	for _, key := range keys {
+		b, ok := l.buckets[key]
+		if !ok {
+			b = rate.NewLimiter(l.limit, l.burst)
+			l.buckets[key] = b
+		}
+		if missing := float64(counts[key]) - b.TokensAt(now); missing > 0 {
+			return &LimitError{Key: key, RetryAfter: time.Duration(missing / float64(l.limit) * float64(time.Second))}
+		}
+	}
+	for key, n := range counts {
+		l.buckets[key].AllowN(now, n)
+	}
+	return nil
+}
+
+// sweep drops the buckets that are full again, which behave as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.TokensAt(now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// RetryAfter formats a delay as the value of a Retry-After header, in whole
+// seconds rounded up.
+func RetryAfter(delay time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(delay.Seconds())), 10)
+}
@@ -0,0 +1,80 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("limits each key", func(t *testing.T) {
+		l := ratelimit.New(1, 2)
+		for range 2 {
+			ok, _ := l.Allow("alice")
+			require.True(t, ok)
+		}
+		ok, wait := l.Allow("alice")
+		require.False(t, ok)
+		require.Greater(t, wait, time.Duration(0))
+		require.LessOrEqual(t, wait, time.Second)
+
+		ok, _ = l.Allow("bob")
+		require.True(t, ok)
+	})
+
+	t.Run("takes tokens for all keys or none", func(t *testing.T) {
+		l := ratelimit.New(1, 2)
+		ok, _ := l.Allow("bob")
+		require.True(t, ok)
+
+		var limitErr *ratelimit.LimitError
+		require.ErrorAs(t, l.AllowAll([]string{"alice", "bob", "bob"}), &limitErr)
+		require.Equal(t, "bob", limitErr.Key)
+		require.Greater(t, limitErr.RetryAfter, time.Duration(0))
+
+		// alice was not charged for the rejected request
+		require.NoError(t, l.AllowAll([]string{"alice", "alice"}))
+		require.NoError(t, l.AllowAll([]string{"bob"}))
+	})
+
+	t.Run("rejects keys repeated more times than the burst", func(t *testing.T) {
+		l := ratelimit.New(1, 2)
+		var burstErr *ratelimit.BurstError
+		require.ErrorAs(t, l.AllowAll([]string{"alice", "bob", "bob", "bob"}), &burstErr)
+		require.Equal(t, "bob", burstErr.Key)
+		require.Equal(t, 3, burstErr.N)
+		require.Equal(t, 2, burstErr.Burst)
+
+		// no token was taken
+		require.NoError(t, l.AllowAll([]string{"alice", "alice", "bob", "bob"}))
+	})
+
+	t.Run("zero rate disables it", func(t *testing.T) {
+		l := ratelimit.New(0, 0)
+		for range 100 {
+			ok, _ := l.Allow("alice")
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("set limit applies to seen keys", func(t *testing.T) {
+		l := ratelimit.New(1, 1)
+		ok, _ := l.Allow("alice")
+		require.True(t, ok)
+		ok, _ = l.Allow("alice")
+		require.False(t, ok)
+
+		l.SetLimit(0, 0)
+		ok, _ = l.Allow("alice")
+		require.True(t, ok)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, "1", ratelimit.RetryAfter(10*time.Millisecond))
+	require.Equal(t, "2", ratelimit.RetryAfter(1500*time.Millisecond))
+	require.Equal(t, "3", ratelimit.RetryAfter(3*time.Second))
+}
@@ -0,0 +1,138 @@
+// Package reload keeps the configuration of a running node, and applies
+// changes to it without a restart.
+package reload
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/app"
+)
+
+var log = logging.Logger("reload")
+
+// Reloadable are the config sections applied to a running node. Changes to any
+// other section need a restart.
+var Reloadable = []string{"logging", "health", "admin", "rate_limit"}
+
+// RestartOnly are the keys of reloadable sections that still need a restart.
+// The log file is opened at startup, so it is not moved while running.
+var RestartOnly = []string{
+	"logging.file",
+	"logging.max_size",
+	"logging.max_backups",
+	"logging.max_age",
+	"logging.compress",
+}
+
+// Loader reads and validates the latest config.
+type Loader func() (config.Config, error)
+
+// Provider holds the current config of the node and notifies subscribers when
+// it changes.
+type Provider struct {
+	reloadMu sync.Mutex
+
+	mu          sync.RWMutex
+	user        config.Config
+	current     app.AppConfig
+	subscribers []func(app.AppConfig)
+}
+
+// NewProvider creates a provider of the config the node was started with.
+func NewProvider(user config.Config, current app.AppConfig) *Provider {
+	return &Provider{user: user, current: current}
+}
+
+// Config returns the current config.
+func (p *Provider) Config() app.AppConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current
+}
+
+// Subscribe registers fn to be called with the new config every time it
+// changes.
+func (p *Provider) Subscribe(fn func(app.AppConfig)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, fn)
+}
+
+// Update applies the reloadable sections of cfg, which must be valid. It
+// returns the keys that changed, split into those applied and those ignored
+// because they need a restart.
+func (p *Provider) Update(cfg config.Config) (applied, ignored []string, err error) {
+	p.mu.Lock()
+	for _, key := range config.Diff(p.user, cfg) {
+		section, _, _ := strings.Cut(key, ".")
+		if slices.Contains(Reloadable, section) && !slices.Contains(RestartOnly, key) {
+			applied = append(applied, key)
+		} else {
+			ignored = append(ignored, key)
+		}
+	}
+	if len(applied) == 0 {
+		p.mu.Unlock()
+		return nil, ignored, nil
+	}
+
+	user, next := p.user, p.current
+	user.Logging, user.Health, user.Admin, user.RateLimit = cfg.Logging, cfg.Health, cfg.Admin, cfg.RateLimit
+	user.Logging.File, user.Logging.MaxSize, user.Logging.MaxBackups, user.Logging.MaxAge, user.Logging.Compress =
+		p.user.Logging.File, p.user.Logging.MaxSize, p.user.Logging.MaxBackups, p.user.Logging.MaxAge, p.user.Logging.Compress
+	if next.Logging, err = user.Logging.ToAppConfig(); err != nil {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("converting logging config to app config: %w", err)
+	}
+	if next.Health, err = cfg.Health.ToAppConfig(); err != nil {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("converting health config to app config: %w", err)
+	}
+	if next.Admin, err = cfg.Admin.ToAppConfig(); err != nil {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("converting admin config to app config: %w", err)
+	}
+	if next.RateLimit, err = cfg.RateLimit.ToAppConfig(); err != nil {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("converting rate limit config to app config: %w", err)
+	}
+	p.user, p.current = user, next
+	subscribers := slices.Clone(p.subscribers)
+	p.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(next)
+	}
+	return applied, ignored, nil
+}
+
+// Reload loads the latest config and applies it. An invalid config is
+// rejected as a whole, leaving the current one in place.
+func (p *Provider) Reload(load Loader) error {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	cfg, err := load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	applied, ignored, err := p.Update(cfg)
+	if err != nil {
+		return err
+	}
+	if len(ignored) > 0 {
+		log.Warnw("Config changes need a restart to take effect, ignoring them", "keys", ignored)
+	}
+	if len(applied) > 0 {
+		log.Infow("Config reloaded", "keys", applied)
+	} else {
+		log.Info("Config reloaded, no changes to apply")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package reload_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/reload"
+)
+
+func baseConfig() config.Config {
+	return config.Config{
+		Server:  config.ServerConfig{Host: "localhost", Port: 3000},
+		Logging: config.LoggingConfig{Level: "warn", Format: "console"},
+		Health:  config.HealthConfig{MinFreeSpace: 1 << 30},
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	user := baseConfig()
+	current := app.AppConfig{
+		Server:  app.ServerConfig{Host: "localhost", Port: 3000},
+		Logging: app.LoggingConfig{Level: "warn", Format: "console"},
+		Health:  app.HealthConfig{MinFreeSpace: 1 << 30},
+	}
+	p := reload.NewProvider(user, current)
+
+	var notified []app.AppConfig
+	p.Subscribe(func(cfg app.AppConfig) {
+		notified = append(notified, cfg)
+	})
+
+	t.Run("applies reloadable sections", func(t *testing.T) {
+		next := baseConfig()
+		next.Logging.Level = "debug"
+		next.Health.DrainDelay = 5 * time.Second
+
+		applied, ignored, err := p.Update(next)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"logging.level", "health.drain_delay"}, applied)
+		require.Empty(t, ignored)
+
+		require.Equal(t, "debug", p.Config().Logging.Level)
+		require.Equal(t, 5*time.Second, p.Config().Health.DrainDelay)
+		require.Len(t, notified, 1)
+	})
+
+	t.Run("ignores sections needing a restart", func(t *testing.T) {
+		next := baseConfig()
+		next.Logging.Level = "debug"
+		next.Health.DrainDelay = 5 * time.Second
+		next.Server.Port = 4000
+		next.Stores.DataDir = "/elsewhere"
+
+		applied, ignored, err := p.Update(next)
+		require.NoError(t, err)
+		require.Empty(t, applied)
+		require.ElementsMatch(t, []string{"server.port", "stores.data_dir"}, ignored)
+
+		require.Equal(t, uint(3000), p.Config().Server.Port)
+		require.Len(t, notified, 1)
+	})
+
+	t.Run("keeps the log file until restart", func(t *testing.T) {
+		next := baseConfig()
+		next.Logging.Level = "info"
+		next.Logging.File = "/var/log/padron.log"
+		next.Logging.MaxSize = 10
+
+		applied, ignored, err := p.Update(next)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"logging.level", "health.drain_delay"}, applied)
+		require.ElementsMatch(t, []string{"logging.file", "logging.max_size"}, ignored)
+
+		require.Equal(t, "info", p.Config().Logging.Level)
+		require.Empty(t, p.Config().Logging.File)
+		require.Zero(t, p.Config().Logging.MaxSize)
+	})
+
+	t.Run("rejects an invalid config", func(t *testing.T) {
+		err := p.Reload(func() (config.Config, error) {
+			return config.Config{}, errors.New("boom")
+		})
+		require.Error(t, err)
+		require.Equal(t, "info", p.Config().Logging.Level)
+	})
+}
@@ -0,0 +1,60 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch calls onChange every time the file at path is written, created or
+// replaced, until the context is done. The directory of the file is watched,
+// so editors and deployments replacing it, e.g. through a symlink swap, are
+// noticed as well.
+//
+// Unlike viper.WatchConfig, Watch does not read the file itself, so the file
+// is only read by the loader passed to [Provider.Reload], under its lock.
+func Watch(ctx context.Context, path string, onChange func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating config file watcher: %w", err)
+	}
+	file := filepath.Clean(path)
+	dir := filepath.Dir(file)
+	if err := w.Add(dir); err != nil {
+		w.Close()
+		return fmt.Errorf("watching config directory %s: %w", dir, err)
+	}
+
+	go func() {
+		defer w.Close()
+		target, _ := filepath.EvalSymlinks(file)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				written := filepath.Clean(event.Name) == file &&
+					(event.Has(fsnotify.Write) || event.Has(fsnotify.Create))
+				// a symlink swap changes the target of the file without an
+				// event for the file itself
+				current, _ := filepath.EvalSymlinks(file)
+				swapped := current != "" && current != target
+				target = current
+				if written || swapped {
+					onChange()
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Errorw("Watching config file", "file", file, "error", err)
+			}
+		}
+	}()
+	return nil
+}
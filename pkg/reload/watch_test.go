@@ -0,0 +1,96 @@
+package reload_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/config"
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/reload"
+)
+
+func writeConfig(t *testing.T, path, level string) {
+	t.Helper()
+	data := fmt.Sprintf("[server]\nhost = 'localhost'\nport = 3000\n\n[logging]\nlevel = '%s'\nformat = 'console'\n", level)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig(t, path, "warn")
+
+	changes := make(chan struct{}, 16)
+	require.NoError(t, reload.Watch(t.Context(), path, func() { changes <- struct{}{} }))
+
+	t.Run("notices writes", func(t *testing.T) {
+		writeConfig(t, path, "debug")
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("change not noticed")
+		}
+	})
+
+	t.Run("notices replacements", func(t *testing.T) {
+		tmp := filepath.Join(filepath.Dir(path), "config.toml.tmp")
+		writeConfig(t, tmp, "info")
+		require.NoError(t, os.Rename(tmp, path))
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("change not noticed")
+		}
+	})
+}
+
+// TestConcurrentReloads reloads the config from a watched file and from
+// direct calls, as SIGHUP does, at the same time. Run with -race to check the
+// file is only read through the locked loader.
+func TestConcurrentReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig(t, path, "warn")
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	load := func() (config.Config, error) {
+		var cfg config.Config
+		if err := v.ReadInConfig(); err != nil {
+			return cfg, err
+		}
+		err := v.Unmarshal(&cfg)
+		return cfg, err
+	}
+
+	user, err := load()
+	require.NoError(t, err)
+	p := reload.NewProvider(user, app.AppConfig{Logging: app.LoggingConfig{Level: "warn", Format: "console"}})
+
+	var wg sync.WaitGroup
+	require.NoError(t, reload.Watch(t.Context(), path, func() {
+		// a file being written may be read half way, which is rejected
+		_ = p.Reload(load)
+	}))
+
+	levels := []string{"debug", "info", "error"}
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.Reload(load)
+		}()
+		writeConfig(t, path, levels[i%len(levels)])
+	}
+	wg.Wait()
+
+	writeConfig(t, path, "debug")
+	require.Eventually(t, func() bool {
+		return p.Config().Logging.Level == "debug"
+	}, 5*time.Second, 10*time.Millisecond)
+}
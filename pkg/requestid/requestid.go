@@ -0,0 +1,76 @@
+// Package requestid correlates the work done for an HTTP request, by carrying
+// the request ID from the X-Request-ID header through the request context.
+package requestid
+
+import (
+	"context"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
+	"go.uber.org/zap"
+)
+
+// Header is the header request IDs are read from and returned in.
+const Header = echo.HeaderXRequestID
+
+type contextKey struct{}
+
+// WithID returns a copy of the context carrying the given request ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by the context, or an empty
+// string if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// MaxLength is the maximum length of a request ID taken from a request.
+const MaxLength = 128
+
+// Valid reports whether a request ID taken from a request can be used as is:
+// it must be non empty, at most [MaxLength] bytes long, and made of printable
+// ASCII characters other than space, as it ends up in logs and signed
+// receipts.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware is an echo middleware that takes the request ID from the request
+// header, or generates one if it is missing or not [Valid], returns it in the
+// response header and stores it in the request context.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			id := r.Header.Get(Header)
+			if !Valid(id) {
+				// same as the echo request ID middleware
+				id = random.String(32)
+			}
+			c.Response().Header().Set(Header, id)
+			c.SetRequest(r.WithContext(WithID(r.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// Logger returns the given logger annotated with the request ID carried by the
+// context, if any.
+func Logger(ctx context.Context, logger *logging.ZapEventLogger) *zap.SugaredLogger {
+	if id := FromContext(ctx); id != "" {
+		return logger.With("request_id", id)
+	}
+	return &logger.SugaredLogger
+}
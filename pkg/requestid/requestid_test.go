@@ -0,0 +1,88 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/volmedo/padron/pkg/requestid"
+)
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(requestid.Middleware())
+
+	var seen string
+	e.GET("/", func(c echo.Context) error {
+		seen = requestid.FromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	t.Run("generates an ID", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.NotEmpty(t, seen)
+		require.Equal(t, seen, rec.Header().Get(requestid.Header))
+	})
+
+	t.Run("propagates the request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "abc123")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		require.Equal(t, "abc123", seen)
+		require.Equal(t, "abc123", rec.Header().Get(requestid.Header))
+	})
+
+	for name, id := range map[string]string{
+		"too long":      strings.Repeat("a", requestid.MaxLength+1),
+		"newline":       "abc\ninjected",
+		"space":         "abc 123",
+		"non-ASCII":     "abc\u00e9",
+		"control chars": "abc\x00",
+	} {
+		t.Run("replaces an ID with "+name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(requestid.Header, id)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.NotEqual(t, id, seen)
+			require.True(t, requestid.Valid(seen))
+			require.Equal(t, seen, rec.Header().Get(requestid.Header))
+		})
+	}
+
+	t.Run("keeps an ID of maximum length", func(t *testing.T) {
+		id := strings.Repeat("a", requestid.MaxLength)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, id)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		require.Equal(t, id, seen)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := &logging.ZapEventLogger{SugaredLogger: *zap.New(core).Sugar()}
+
+	requestid.Logger(requestid.WithID(t.Context(), "abc123"), logger).Info("with ID")
+	requestid.Logger(t.Context(), logger).Info("without ID")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	require.Equal(t, "abc123", entries[0].ContextMap()["request_id"])
+	require.NotContains(t, entries[1].ContextMap(), "request_id")
+}
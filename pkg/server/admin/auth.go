@@ -0,0 +1,50 @@
+// Package admin provides HTTP handlers to operate a running node.
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+
+	"github.com/volmedo/padron/pkg/server"
+)
+
+var log = logging.Logger("server/admin")
+
+// Authenticate is a middleware that rejects requests not presenting the
+// current token as a bearer token in the Authorization header. While the
+// token is empty, the endpoints are disabled and respond not found.
+func Authenticate(token func() string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			token := token()
+			if token == "" {
+				return echo.ErrNotFound
+			}
+			auth := ctx.Request().Header.Get(echo.HeaderAuthorization)
+			presented, ok := strings.CutPrefix(auth, "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				log.Warnw("rejected admin request", "path", ctx.Request().URL.Path, "remote", ctx.RealIP())
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid admin token")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// RequireClientCert is a middleware that rejects requests whose TLS client
+// did not present a verified certificate.
+func RequireClientCert() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !server.HasClientCert(ctx.Request().TLS) {
+				log.Warnw("rejected admin request without client certificate", "path", ctx.Request().URL.Path, "remote", ctx.RealIP())
+				return echo.NewHTTPError(http.StatusForbidden, "client certificate required")
+			}
+			return next(ctx)
+		}
+	}
+}
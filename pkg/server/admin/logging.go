@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/logs"
+)
+
+// LoggingSettings are the logging settings reported and accepted by the admin
+// logging endpoint.
+type LoggingSettings struct {
+	Level      string            `json:"level"`
+	Subsystems map[string]string `json:"subsystems,omitempty"`
+	Format     string            `json:"format"`
+	File       string            `json:"file,omitempty"`
+	MaxSize    int               `json:"maxSize"`
+	MaxBackups int               `json:"maxBackups"`
+	MaxAge     int               `json:"maxAge"`
+	Compress   bool              `json:"compress"`
+}
+
+func toSettings(cfg app.LoggingConfig) LoggingSettings {
+	return LoggingSettings{
+		Level:      cfg.Level,
+		Subsystems: cfg.Subsystems,
+		Format:     cfg.Format,
+		File:       cfg.File,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+		Compress:   cfg.Compress,
+	}
+}
+
+// LoggingPatch are the logging settings that can be changed at runtime. The
+// log file and its rotation are set up at startup and cannot be changed.
+type LoggingPatch struct {
+	Level      *string           `json:"level,omitempty"`
+	Subsystems map[string]string `json:"subsystems,omitempty"`
+	Format     *string           `json:"format,omitempty"`
+}
+
+// NewLoggingGetHandler reports the current logging settings, along with the
+// level of every known subsystem.
+func NewLoggingGetHandler() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		settings := toSettings(logs.Config())
+		settings.Subsystems = logging.SubsystemLevelNames()
+		return ctx.JSON(http.StatusOK, settings)
+	}
+}
+
+// NewLoggingPatchHandler updates the logging settings present in the request
+// body, leaving the rest untouched, and responds with the resulting settings.
+// Requests changing anything other than levels and format are rejected.
+func NewLoggingPatchHandler() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		var patch LoggingPatch
+		dec := json.NewDecoder(ctx.Request().Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&patch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("decoding logging settings, only level, subsystems and format can be changed: %s", err))
+		}
+
+		cfg := logs.Config()
+		if patch.Level != nil {
+			cfg.Level = *patch.Level
+		}
+		if patch.Format != nil {
+			cfg.Format = *patch.Format
+		}
+		if len(patch.Subsystems) > 0 {
+			subsystems := maps.Clone(cfg.Subsystems)
+			if subsystems == nil {
+				subsystems = map[string]string{}
+			}
+			maps.Copy(subsystems, patch.Subsystems)
+			cfg.Subsystems = subsystems
+		}
+		if err := logs.Setup(cfg); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		log.Infow("updated logging settings", "level", cfg.Level, "subsystems", cfg.Subsystems, "format", cfg.Format)
+		return ctx.JSON(http.StatusOK, toSettings(logs.Config()))
+	}
+}
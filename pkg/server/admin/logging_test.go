@@ -0,0 +1,93 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/config/app"
+	"github.com/volmedo/padron/pkg/logs"
+	"github.com/volmedo/padron/pkg/server/admin"
+)
+
+func TestLoggingPatchHandler(t *testing.T) {
+	initial := logs.Config()
+	t.Cleanup(func() { require.NoError(t, logs.Setup(initial)) })
+
+	e := echo.New()
+	e.GET("/logging", admin.NewLoggingGetHandler())
+	e.PATCH("/logging", admin.NewLoggingPatchHandler())
+
+	patch := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/logging", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	reset := func(t *testing.T) {
+		require.NoError(t, logs.Setup(app.LoggingConfig{
+			Level:      "warn",
+			Subsystems: map[string]string{"service/blob": "info"},
+			Format:     "console",
+		}))
+	}
+
+	t.Run("changes levels and format", func(t *testing.T) {
+		reset(t)
+		rec := patch(t, `{"level":"debug","subsystems":{"server/blob":"error"},"format":"json"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var settings admin.LoggingSettings
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &settings))
+		require.Equal(t, "debug", settings.Level)
+		require.Equal(t, "json", settings.Format)
+
+		cfg := logs.Config()
+		require.Equal(t, "debug", cfg.Level)
+		require.Equal(t, "json", cfg.Format)
+		require.Equal(t, map[string]string{"service/blob": "info", "server/blob": "error"}, cfg.Subsystems)
+	})
+
+	t.Run("leaves missing settings untouched", func(t *testing.T) {
+		reset(t)
+		rec := patch(t, `{"format":"json"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "warn", logs.Config().Level)
+		require.Equal(t, "json", logs.Config().Format)
+	})
+
+	t.Run("rejects invalid level", func(t *testing.T) {
+		reset(t)
+		rec := patch(t, `{"level":"loud"}`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, "warn", logs.Config().Level)
+	})
+
+	for _, body := range []string{
+		`{"file":"` + filepath.Join(t.TempDir(), "padron.log") + `"}`,
+		`{"maxSize":1}`,
+		`{"maxBackups":1}`,
+		`{"maxAge":1}`,
+		`{"compress":true}`,
+		`{"level":"debug","file":"/tmp/padron.log"}`,
+	} {
+		t.Run("rejects "+body, func(t *testing.T) {
+			reset(t)
+			rec := patch(t, body)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+
+			cfg := logs.Config()
+			require.Equal(t, "warn", cfg.Level)
+			require.Empty(t, cfg.File)
+			require.Zero(t, cfg.MaxSize)
+		})
+	}
+}
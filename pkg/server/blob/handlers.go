@@ -5,13 +5,23 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ucan/container"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
 )
 
 var log = logging.Logger("server/blob")
@@ -24,6 +34,7 @@ func NewBlobGetHandler(blobs blobstore.Blobstore) echo.HandlerFunc {
 			w := ctx.Response()
 			r.URL.Path = r.URL.Path[len("/blob"):]
 			serveHTTP(w, r)
+			metrics.AddBytesServed(w.Size)
 			return nil
 		}
 	}
@@ -34,8 +45,13 @@ func NewBlobGetHandler(blobs blobstore.Blobstore) echo.HandlerFunc {
 	}
 }
 
-func NewBlobPutHandler(allocs allocationstore.AllocationStore, blobs blobstore.Blobstore) echo.HandlerFunc {
+func NewBlobPutHandler(allocs allocationstore.AllocationStore, blobs blobstore.Blobstore, now func() time.Time) echo.HandlerFunc {
+	if now == nil {
+		now = time.Now
+	}
+
 	return func(ctx echo.Context) error {
+		log := requestid.Logger(ctx.Request().Context(), log)
 		digest := ctx.Param("blob")
 		mh, err := digestutil.Parse(digest)
 		if err != nil {
@@ -54,7 +70,7 @@ func NewBlobPutHandler(allocs allocationstore.AllocationStore, blobs blobstore.B
 		expired := true
 		for _, a := range results {
 			exp := a.Expires
-			if exp > uint64(time.Now().Unix()) {
+			if exp > uint64(now().Unix()) {
 				expired = false
 				break
 			}
@@ -80,8 +96,44 @@ func NewBlobPutHandler(allocs allocationstore.AllocationStore, blobs blobstore.B
 
 			return fmt.Errorf("write failed: %w", err)
 		}
+		metrics.AddBytesUploaded(uint64(contentLength))
 
 		ctx.Response().WriteHeader(http.StatusOK)
 		return nil
 	}
 }
+
+// NewCommitmentGetHandler serves the current location commitment for a blob in
+// a space, so clients can pick up renewed commitments. It is returned in a UCAN
+// container, or as a CAR archive when the legacy ucanto commitment is asked for
+// and one was issued.
+func NewCommitmentGetHandler(commitments commitmentstore.CommitmentStore) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		digest, err := digestutil.Parse(ctx.Param("blob"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("decoding multibase encoded digest: %w", err))
+		}
+		space, err := did.Parse(ctx.Param("space"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("parsing space DID: %w", err))
+		}
+
+		c, err := commitments.Get(ctx.Request().Context(), digest, space)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return echo.NewHTTPError(http.StatusNotFound, "location commitment not found")
+			}
+			return fmt.Errorf("getting location commitment: %w", err)
+		}
+
+		w := ctx.Response()
+		w.Header().Set("Cache-Control", "no-cache")
+		if c.Legacy != nil && strings.Contains(ctx.Request().Header.Get("Accept"), car.ContentType) {
+			return ctx.Blob(http.StatusOK, car.ContentType, c.Legacy)
+		}
+
+		w.Header().Set(echo.HeaderContentType, dagcbor.ContentType)
+		w.WriteHeader(http.StatusOK)
+		return container.New(container.WithDelegations(c.Delegation)).MarshalCBOR(w)
+	}
+}
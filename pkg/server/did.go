@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal"
+)
+
+// DIDDocumentPath is where the DID document of a did:web without a path is
+// served.
+const DIDDocumentPath = "/.well-known/did.json"
+
+// DIDDocument is a DID document listing the ed25519 keys of a DID.
+type DIDDocument struct {
+	Context            []string             `json:"@context"`
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+	Authentication     []string             `json:"authentication"`
+	AssertionMethod    []string             `json:"assertionMethod"`
+}
+
+// VerificationMethod is a public key of a [DIDDocument].
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
+// NewDIDDocument creates the DID document of the DID, with a verification
+// method for each key. Keys must be did:key verifiers, and are referenced
+// from the document by their multibase encoded public key.
+func NewDIDDocument(id did.DID, keys ...principal.Verifier) DIDDocument {
+	doc := DIDDocument{
+		Context: []string{
+			"https://www.w3.org/ns/did/v1",
+			"https://w3id.org/security/suites/ed25519-2020/v1",
+		},
+		ID:                 id.String(),
+		VerificationMethod: []VerificationMethod{},
+		Authentication:     []string{},
+		AssertionMethod:    []string{},
+	}
+	for _, k := range keys {
+		mb := strings.TrimPrefix(k.DID().String(), did.KeyPrefix)
+		vm := VerificationMethod{
+			ID:                 id.String() + "#" + mb,
+			Type:               "Ed25519VerificationKey2020",
+			Controller:         id.String(),
+			PublicKeyMultibase: mb,
+		}
+		doc.VerificationMethod = append(doc.VerificationMethod, vm)
+		doc.Authentication = append(doc.Authentication, vm.ID)
+		doc.AssertionMethod = append(doc.AssertionMethod, vm.ID)
+	}
+	return doc
+}
+
+// NewDIDDocumentHandler serves the DID document of the DID.
+func NewDIDDocumentHandler(id did.DID, keys ...principal.Verifier) http.Handler {
+	doc := NewDIDDocument(id, keys...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/did+json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			log.Errorw("encoding DID document", "error", err)
+		}
+	})
+}
@@ -4,34 +4,145 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/alanshaw/ucantone/ucan"
 	logging "github.com/ipfs/go-log/v2"
+	mh "github.com/multiformats/go-multihash"
 	"github.com/volmedo/padron/pkg/build"
+	"github.com/volmedo/padron/pkg/internal/disk"
 )
 
 var log = logging.Logger("server")
 
 type ServerInfo struct {
-	ID    string    `json:"id"`
-	Build BuildInfo `json:"build"`
+	ID        string       `json:"id"`
+	PublicURL string       `json:"publicUrl,omitempty"`
+	Build     BuildInfo    `json:"build"`
+	Uptime    int64        `json:"uptime"`
+	UCAN      UCANInfo     `json:"ucan"`
+	Blob      BlobInfo     `json:"blob"`
+	Storage   *StorageInfo `json:"storage,omitempty"`
 }
 
 type BuildInfo struct {
 	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	BuiltBy string `json:"builtBy"`
 	Repo    string `json:"repo"`
 }
 
-func NewRootHandler(id ucan.Principal) http.Handler {
+type UCANInfo struct {
+	Commands []string `json:"commands"`
+}
+
+type BlobInfo struct {
+	MaxUploadSize  uint64   `json:"maxUploadSize,omitempty"`
+	MultihashCodes []string `json:"multihashCodes"`
+}
+
+// StorageInfo reports the usage of the filesystem holding the data directory,
+// in bytes.
+type StorageInfo struct {
+	Capacity  uint64 `json:"capacity"`
+	Used      uint64 `json:"used"`
+	Available uint64 `json:"available"`
+}
+
+type rootConfig struct {
+	publicURL      *url.URL
+	commands       []string
+	maxUploadSize  uint64
+	multihashCodes []uint64
+	storageDir     string
+}
+
+// RootOption configures the information reported by the root handler.
+type RootOption func(*rootConfig)
+
+// WithPublicURL reports the public URL of the node.
+func WithPublicURL(u *url.URL) RootOption {
+	return func(c *rootConfig) {
+		c.publicURL = u
+	}
+}
+
+// WithCommands reports the UCAN commands the node handles.
+func WithCommands(commands ...string) RootOption {
+	return func(c *rootConfig) {
+		c.commands = append(c.commands, commands...)
+	}
+}
+
+// WithMaxUploadSize reports the maximum size of a blob the node accepts.
+func WithMaxUploadSize(size uint64) RootOption {
+	return func(c *rootConfig) {
+		c.maxUploadSize = size
+	}
+}
+
+// WithMultihashCodes reports the multihash codes of the blob digests the node
+// accepts.
+func WithMultihashCodes(codes ...uint64) RootOption {
+	return func(c *rootConfig) {
+		c.multihashCodes = append(c.multihashCodes, codes...)
+	}
+}
+
+// WithStorageDir reports the capacity and usage of the filesystem holding the
+// given directory.
+func WithStorageDir(dir string) RootOption {
+	return func(c *rootConfig) {
+		c.storageDir = dir
+	}
+}
+
+func NewRootHandler(id ucan.Principal, options ...RootOption) http.Handler {
+	cfg := rootConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	started := time.Now()
 	info := ServerInfo{
 		ID: id.DID().String(),
 		Build: BuildInfo{
 			Version: build.Version,
+			Commit:  build.Commit,
+			Date:    build.Date,
+			BuiltBy: build.BuiltBy,
 			Repo:    "https://github.com/volmedo/padron",
 		},
+		UCAN: UCANInfo{
+			Commands: append([]string{}, cfg.commands...),
+		},
+		Blob: BlobInfo{
+			MaxUploadSize:  cfg.maxUploadSize,
+			MultihashCodes: []string{},
+		},
 	}
+	if cfg.publicURL != nil {
+		info.PublicURL = cfg.publicURL.String()
+	}
+	for _, code := range cfg.multihashCodes {
+		info.Blob.MultihashCodes = append(info.Blob.MultihashCodes, multihashName(code))
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := info
+		info.Uptime = int64(time.Since(started).Seconds())
+		if cfg.storageDir != "" {
+			total, free, err := disk.Usage(cfg.storageDir)
+			if err != nil {
+				log.Warnw("reading filesystem capacity", "dir", cfg.storageDir, "error", err)
+			} else {
+				info.Storage = &StorageInfo{Capacity: total, Used: total - free, Available: free}
+			}
+		}
+
 		if strings.Contains(r.Header.Get("Accept"), "application/json") {
 			w.Header().Set("Content-Type", "application/json")
 			data, err := json.Marshal(&info)
@@ -49,8 +160,32 @@ func NewRootHandler(id ucan.Principal) http.Handler {
 			w.Write([]byte("▌            ▀▘\n"))
 			w.Write([]byte("\n"))
 			fmt.Fprintf(w, "🫑 padrón %s\n", info.Build.Version)
+			fmt.Fprintf(w, "🔨 %s built %s by %s\n", info.Build.Commit, info.Build.Date, info.Build.BuiltBy)
 			fmt.Fprintf(w, "🆔 %s\n", info.ID)
+			if info.PublicURL != "" {
+				fmt.Fprintf(w, "🌐 %s\n", info.PublicURL)
+			}
+			fmt.Fprintf(w, "⏱️ up %s\n", time.Duration(info.Uptime)*time.Second)
+			if len(info.UCAN.Commands) > 0 {
+				fmt.Fprintf(w, "📜 %s\n", strings.Join(info.UCAN.Commands, ", "))
+			}
+			if len(info.Blob.MultihashCodes) > 0 {
+				fmt.Fprintf(w, "#️⃣ %s\n", strings.Join(info.Blob.MultihashCodes, ", "))
+			}
+			if info.Blob.MaxUploadSize > 0 {
+				fmt.Fprintf(w, "📦 max upload %d bytes\n", info.Blob.MaxUploadSize)
+			}
+			if info.Storage != nil {
+				fmt.Fprintf(w, "💾 %d of %d bytes used, %d available\n", info.Storage.Used, info.Storage.Capacity, info.Storage.Available)
+			}
 			w.Write([]byte("🐙 https://github.com/volmedo/padron\n"))
 		}
 	})
 }
+
+func multihashName(code uint64) string {
+	if name, ok := mh.Codes[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", code)
+}
@@ -1,23 +1,49 @@
 package server_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/alanshaw/ucantone/did"
 	"github.com/alanshaw/ucantone/principal/ed25519"
+	mh "github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/require"
 	"github.com/volmedo/padron/pkg/build"
 	"github.com/volmedo/padron/pkg/server"
 )
 
 func TestVersionInfoHandler(t *testing.T) {
+	commit := build.Commit
+	build.Commit = "8f2c1e0d9b7a"
+	t.Cleanup(func() { build.Commit = commit })
+
 	id, err := ed25519.Generate()
 	require.NoError(t, err)
 
-	ts := httptest.NewServer(server.NewRootHandler(id))
+	publicURL, err := url.Parse("https://padron.example.com")
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(server.NewRootHandler(
+		id,
+		server.WithPublicURL(publicURL),
+		server.WithCommands("/blob/allocate", "/blob/accept"),
+		server.WithMultihashCodes(mh.SHA2_256),
+		server.WithStorageDir(t.TempDir()),
+	))
 	defer ts.Close()
 
 	t.Run("text/plain", func(t *testing.T) {
@@ -30,6 +56,10 @@ func TestVersionInfoHandler(t *testing.T) {
 
 		require.Contains(t, string(body), id.DID().String())
 		require.Contains(t, string(body), build.Version)
+		require.Contains(t, string(body), "8f2c1e0d9b7a")
+		require.Contains(t, string(body), publicURL.String())
+		require.Contains(t, string(body), "/blob/allocate, /blob/accept")
+		require.Contains(t, string(body), "sha2-256")
 	})
 
 	t.Run("application/json", func(t *testing.T) {
@@ -50,5 +80,100 @@ func TestVersionInfoHandler(t *testing.T) {
 
 		require.Equal(t, id.DID().String(), info.ID)
 		require.Equal(t, build.Version, info.Build.Version)
+		require.Equal(t, "8f2c1e0d9b7a", info.Build.Commit)
+		require.Equal(t, publicURL.String(), info.PublicURL)
+		require.Equal(t, []string{"/blob/allocate", "/blob/accept"}, info.UCAN.Commands)
+		require.Equal(t, []string{"sha2-256"}, info.Blob.MultihashCodes)
+		require.NotNil(t, info.Storage)
+		require.NotZero(t, info.Storage.Capacity)
+	})
+}
+
+func TestDIDDocumentHandler(t *testing.T) {
+	key, err := ed25519.Generate()
+	require.NoError(t, err)
+	id, err := did.Parse("did:web:example.com")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	server.NewDIDDocumentHandler(id, key.Verifier()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, server.DIDDocumentPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/did+json", rec.Header().Get("Content-Type"))
+
+	var doc server.DIDDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Equal(t, "did:web:example.com", doc.ID)
+	require.Len(t, doc.VerificationMethod, 1)
+
+	vm := doc.VerificationMethod[0]
+	require.Equal(t, "did:key:"+vm.PublicKeyMultibase, key.DID().String())
+	require.Equal(t, "did:web:example.com", vm.Controller)
+	require.Equal(t, []string{vm.ID}, doc.AssertionMethod)
+}
+
+// writeCert writes a self-signed certificate for the given host, and its key.
+func writeCert(t *testing.T, certFile, keyFile, host string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	now := time.Now()
+	writeCert(t, certFile, keyFile, "one.example.com", now.Add(-time.Minute))
+
+	certs, err := server.NewCertReloader(certFile, keyFile, server.WithCheckInterval(0))
+	require.NoError(t, err)
+
+	commonName := func() string {
+		cert, err := certs.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	require.Equal(t, "one.example.com", commonName())
+
+	t.Run("reloads changed files", func(t *testing.T) {
+		writeCert(t, certFile, keyFile, "two.example.com", now)
+		require.Equal(t, "two.example.com", commonName())
+	})
+
+	t.Run("keeps the previous certificate if the files are invalid", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0600))
+		require.NoError(t, os.Chtimes(certFile, now.Add(time.Minute), now.Add(time.Minute)))
+		require.Equal(t, "two.example.com", commonName())
+	})
+
+	t.Run("checks the files once per interval", func(t *testing.T) {
+		writeCert(t, certFile, keyFile, "three.example.com", now.Add(2*time.Minute))
+		certs, err := server.NewCertReloader(certFile, keyFile, server.WithCheckInterval(time.Hour))
+		require.NoError(t, err)
+
+		writeCert(t, certFile, keyFile, "four.example.com", now.Add(3*time.Minute))
+		cert, err := certs.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		require.Equal(t, "three.example.com", leaf.Subject.CommonName)
 	})
 }
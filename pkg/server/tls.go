@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertCheckInterval is how often the certificate files are checked for
+// changes.
+const DefaultCertCheckInterval = 10 * time.Second
+
+// CertReloader serves a certificate and private key loaded from PEM files, and
+// loads them again when they change on disk, e.g. after a renewal.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+	checked time.Time
+}
+
+// CertReloaderOption configures a [CertReloader].
+type CertReloaderOption func(*CertReloader)
+
+// WithCheckInterval sets how often the files are checked for changes.
+// Defaults to [DefaultCertCheckInterval]. Zero checks them on every handshake.
+func WithCheckInterval(interval time.Duration) CertReloaderOption {
+	return func(r *CertReloader) {
+		r.interval = interval
+	}
+}
+
+// NewCertReloader loads the certificate and key in the given files.
+func NewCertReloader(certFile, keyFile string, options ...CertReloaderOption) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, interval: DefaultCertCheckInterval}
+	for _, opt := range options {
+		opt(r)
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.checked = time.Now()
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, to be used as
+// [tls.Config.GetCertificate]. The files are checked for changes at most once
+// per check interval, so handshakes do not wait on the filesystem. If they
+// changed but cannot be loaded, the previous certificate keeps being served.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < r.interval {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+
+	certMod, keyMod, err := modTimes(r.certFile, r.keyFile)
+	if err != nil {
+		log.Errorw("Checking TLS certificate files, serving the previous certificate", "error", err)
+	} else if !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod) {
+		if err := r.load(); err != nil {
+			log.Errorw("Reloading TLS certificate, serving the previous one", "error", err)
+		} else {
+			log.Infow("Reloaded TLS certificate", "cert_file", r.certFile)
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *CertReloader) load() error {
+	certMod, keyMod, err := modTimes(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func modTimes(certFile, keyFile string) (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reading TLS certificate file: %w", err)
+	}
+	keyInfo, err := os.Stat(keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("reading TLS key file: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// NewTLSConfig creates the TLS config of the server, serving the certificates
+// of certs. When clientCAs is not nil, clients may present a certificate
+// signed by one of them, see [HasClientCert].
+func NewTLSConfig(certs *CertReloader, clientCAs *x509.CertPool) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	if clientCAs != nil {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = clientCAs
+	}
+	return cfg
+}
+
+// HasClientCert reports whether the client of a TLS connection presented a
+// certificate that was verified against the configured client CAs.
+func HasClientCert(state *tls.ConnectionState) bool {
+	return state != nil && len(state.VerifiedChains) > 0
+}
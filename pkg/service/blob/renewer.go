@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Renewer periodically renews the location commitments issued by a [Service]
+// before they expire.
+type Renewer struct {
+	svc      *Service
+	interval time.Duration
+	window   time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRenewer creates a renewer that checks for expiring commitments every
+// interval, renewing those that expire within the given window.
+func NewRenewer(svc *Service, interval, window time.Duration) *Renewer {
+	return &Renewer{
+		svc:      svc,
+		interval: interval,
+		window:   window,
+	}
+}
+
+// Start runs the renewal loop in the background until [Renewer.Stop] is called.
+func (r *Renewer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			r.renew(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the renewal loop and waits for any in-flight renewal to finish.
+func (r *Renewer) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Renewer) renew(ctx context.Context) {
+	err := r.svc.RenewCommitments(ctx, r.window)
+	if err != nil && ctx.Err() == nil {
+		log.Errorw("renewing location commitments", "error", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
 
 	"github.com/alanshaw/libracha/capabilities/assert"
@@ -25,16 +26,95 @@ import (
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
 	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/tracing"
+	padronucan "github.com/volmedo/padron/pkg/ucan"
 )
 
 var log = logging.Logger("service/blob")
 
+// MaxUploadSize is the maximum size of a blob that can be allocated.
+const MaxUploadSize = 127 * (1 << 25)
+
+// SupportedDigests are the multihash codes of the blob digests the node can
+// verify and store.
+var SupportedDigests = []uint64{mh.SHA2_256}
+
+// DigestSupported reports whether a blob digest is a multihash of one of the
+// [SupportedDigests].
+func DigestSupported(digest mh.Multihash) bool {
+	dh, err := mh.Decode(digest)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(SupportedDigests, dh.Code)
+}
+
+// AllocationTTL is how long an allocation waits for the blob to be uploaded.
+const AllocationTTL = 24 * time.Hour
+
+// DefaultCommitmentTTL is the default lifetime of location commitments.
+const DefaultCommitmentTTL = 30 * 24 * time.Hour
+
 type Service struct {
-	id          ucan.Signer
-	publicURL   *url.URL
-	blobs       blobstore.Blobstore
-	allocations allocationstore.AllocationStore
-	acceptances acceptancestore.AcceptanceStore
+	id            ucan.Signer
+	publicURL     *url.URL
+	mirrorURLs    []*url.URL
+	blobs         blobstore.Blobstore
+	allocations   allocationstore.AllocationStore
+	acceptances   acceptancestore.AcceptanceStore
+	commitments   commitmentstore.CommitmentStore
+	commitmentTTL time.Duration
+	legacy        LegacyCommitFunc
+	now           func() time.Time
+}
+
+// LegacyCommitFunc issues a legacy ucanto location commitment for the blob in
+// the space, returning it archived.
+type LegacyCommitFunc func(space did.DID, blob Blob, locations []*url.URL, expiration time.Time) ([]byte, error)
+
+// Option is an option configuring the blob service.
+type Option func(s *Service)
+
+// WithClock sets the function used to obtain the current time, e.g. when
+// calculating allocation expiry. Defaults to [time.Now].
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithMirrorURLs sets additional public base URLs blobs can be retrieved from,
+// e.g. a CDN in front of the node. They are advertised in location commitments
+// after the public URL.
+func WithMirrorURLs(urls ...*url.URL) Option {
+	return func(s *Service) {
+		s.mirrorURLs = urls
+	}
+}
+
+// WithCommitmentTTL sets the lifetime of the location commitments issued for
+// accepted blobs. Defaults to [DefaultCommitmentTTL].
+func WithCommitmentTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.commitmentTTL = ttl
+		}
+	}
+}
+
+// WithLegacyCommitter sets the function issuing legacy ucanto location
+// commitments, for blobs accepted through [Service.AcceptLegacy]. Without it
+// only UCAN 1.0 commitments can be issued.
+func WithLegacyCommitter(commit LegacyCommitFunc) Option {
+	return func(s *Service) {
+		s.legacy = commit
+	}
 }
 
 func NewService(
@@ -43,14 +123,23 @@ func NewService(
 	blobs blobstore.Blobstore,
 	allocations allocationstore.AllocationStore,
 	acceptances acceptancestore.AcceptanceStore,
+	commitments commitmentstore.CommitmentStore,
+	options ...Option,
 ) *Service {
-	return &Service{
-		id:          id,
-		publicURL:   publicURL,
-		blobs:       blobs,
-		allocations: allocations,
-		acceptances: nil,
+	s := &Service{
+		id:            id,
+		publicURL:     publicURL,
+		blobs:         blobs,
+		allocations:   allocations,
+		acceptances:   acceptances,
+		commitments:   commitments,
+		commitmentTTL: DefaultCommitmentTTL,
+		now:           time.Now,
 	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
 }
 
 type Blob struct {
@@ -64,8 +153,16 @@ type Address struct {
 	Expires time.Time
 }
 
-func (s *Service) Allocate(ctx context.Context, space did.DID, blob Blob, cause ucan.Link) (uint64, *Address, error) {
-	log := log.With("space", space.String(), "blob", digestutil.Format(blob.Digest))
+func (s *Service) Allocate(ctx context.Context, space did.DID, blob Blob, cause ucan.Link) (_ uint64, _ *Address, err error) {
+	ctx, span := tracing.Start(ctx, "blob.Allocate",
+		tracing.Space(space.String()),
+		tracing.Digest(blob.Digest),
+		tracing.Size(blob.Size),
+		tracing.Invocation(cause.String()),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	log := requestid.Logger(ctx, log).With("space", space.String(), "blob", digestutil.Format(blob.Digest))
 	log.Infof("allocating blob of size %d", blob.Size)
 
 	// check if we already have an allocation for the blob in this space
@@ -111,14 +208,14 @@ func (s *Service) Allocate(ctx context.Context, space did.DID, blob Blob, cause
 		return size, nil, nil
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
+	expiresAt := s.now().Add(AllocationTTL)
 
 	var address *Address
 	// if not received yet, we need to generate a signed URL for the
 	// upload, and include it in the receipt.
 	if !received {
 		address = &Address{
-			URL:     s.getBlobURL(blob.Digest),
+			URL:     s.BlobURL(blob.Digest),
 			Headers: http.Header{},
 			Expires: expiresAt,
 		}
@@ -137,63 +234,216 @@ func (s *Service) Allocate(ctx context.Context, space did.DID, blob Blob, cause
 		log.Errorw("putting allocation", "error", err)
 		return 0, nil, fmt.Errorf("putting allocation: %w", err)
 	}
+	metrics.IncAllocations()
 
 	return size, address, nil
 }
 
+// Accept records the acceptance of an uploaded blob in the space and issues a
+// location commitment for it.
 func (s *Service) Accept(ctx context.Context, space did.DID, blob Blob, cause ucan.Link) (*delegation.Delegation, error) {
-	log := log.With("space", space.String(), "blob", digestutil.Format(blob.Digest))
-	log.Infof("allocating blob of size %d", blob.Size)
+	c, err := s.accept(ctx, space, blob, cause, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.Delegation, nil
+}
+
+// AcceptLegacy is like [Service.Accept], but also issues a legacy ucanto
+// location commitment, which is returned archived. Both are renewed together.
+func (s *Service) AcceptLegacy(ctx context.Context, space did.DID, blob Blob, cause ucan.Link) ([]byte, error) {
+	if s.legacy == nil {
+		return nil, errors.New("legacy location commitments are not supported")
+	}
+	c, err := s.accept(ctx, space, blob, cause, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.Legacy, nil
+}
+
+func (s *Service) accept(ctx context.Context, space did.DID, blob Blob, cause ucan.Link, legacy bool) (_ commitmentstore.Commitment, err error) {
+	ctx, span := tracing.Start(ctx, "blob.Accept",
+		tracing.Space(space.String()),
+		tracing.Digest(blob.Digest),
+		tracing.Size(blob.Size),
+		tracing.Invocation(cause.String()),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	log := requestid.Logger(ctx, log).With("space", space.String(), "blob", digestutil.Format(blob.Digest))
+	log.Infof("accepting blob of size %d", blob.Size)
 
 	// check if we already got the blob
-	_, err := s.blobs.Get(ctx, blob.Digest)
+	_, err = s.blobs.Get(ctx, blob.Digest)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
-			return nil, fmt.Errorf("blob not found: %w", err)
+			return commitmentstore.Commitment{}, fmt.Errorf("blob not found: %w", err)
 		}
 
 		log.Errorw("getting blob", "error", err)
-		return nil, fmt.Errorf("getting blob: %w", err)
+		return commitmentstore.Commitment{}, fmt.Errorf("getting blob: %w", err)
 	}
 
 	sp, _ := ucantodid.Parse(space.String())
 	acc := acceptance.Acceptance{
 		Space:      sp,
 		Blob:       acceptance.Blob(blob),
-		ExecutedAt: uint64(time.Now().Unix()),
+		ExecutedAt: uint64(s.now().Unix()),
 		Cause:      cidlink.Link{Cid: cid.Cid(cause)},
 	}
 
 	err = s.acceptances.Put(ctx, acc)
 	if err != nil {
 		log.Errorw("putting acceptance for blob", "error", err)
-		return nil, fmt.Errorf("putting acceptance for blob: %w", err)
+		return commitmentstore.Commitment{}, fmt.Errorf("putting acceptance for blob: %w", err)
+	}
+	metrics.IncAcceptances()
+
+	c, err := s.commit(ctx, space, blob, legacy)
+	if err != nil {
+		log.Errorw("issuing location commitment", "error", err)
+		return commitmentstore.Commitment{}, err
 	}
 
-	// build location commitment
+	return c, nil
+}
+
+// RenewCommitments re-issues the location commitments that expire within the
+// given window, for blobs that are still held. Commitments for blobs that have
+// been removed are dropped, so they lapse at their expiration. A failure to
+// renew one commitment does not stop the others from being renewed.
+func (s *Service) RenewCommitments(ctx context.Context, window time.Duration) (err error) {
+	ctx, span := tracing.Start(ctx, "blob.RenewCommitments")
+	defer func() { tracing.End(span, err) }()
+
+	before := uint64(s.now().Add(window).Unix())
+	expiring, err := s.commitments.ListExpiring(ctx, before)
+	if err != nil {
+		return fmt.Errorf("listing expiring commitments: %w", err)
+	}
+
+	failed := 0
+	for _, c := range expiring {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log := requestid.Logger(ctx, log).With("space", c.Space.String(), "blob", digestutil.Format(c.Digest))
+		err := s.renew(ctx, c)
+		if err != nil {
+			log.Errorw("renewing location commitment", "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("renewing %d of %d location commitments failed", failed, len(expiring))
+	}
+	return nil
+}
+
+func (s *Service) renew(ctx context.Context, c commitmentstore.Commitment) error {
+	log := requestid.Logger(ctx, log).With("space", c.Space.String(), "blob", digestutil.Format(c.Digest))
+
+	_, err := s.blobs.Get(ctx, c.Digest)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("getting blob: %w", err)
+		}
+
+		log.Info("blob no longer held, letting location commitment lapse")
+		err = s.commitments.Delete(ctx, c.Digest, c.Space)
+		if err != nil {
+			return fmt.Errorf("deleting location commitment: %w", err)
+		}
+		return nil
+	}
+
+	_, err = s.commit(ctx, c.Space, Blob{Digest: c.Digest, Size: c.Size}, c.Legacy != nil)
+	if err != nil {
+		return err
+	}
+	log.Info("renewed location commitment")
+	return nil
+}
+
+// CommitmentExpiration returns the expiration time of a location commitment
+// issued now.
+func (s *Service) CommitmentExpiration() time.Time {
+	return s.now().Add(s.commitmentTTL)
+}
+
+// commit issues a location commitment for the blob in the space and stores it,
+// replacing any previous commitment, so it can be renewed before it expires.
+// With legacy set, a legacy ucanto commitment is issued and stored too.
+func (s *Service) commit(ctx context.Context, space did.DID, blob Blob, legacy bool) (commitmentstore.Commitment, error) {
+	expiration := s.CommitmentExpiration()
+	exp := uint64(expiration.Unix())
+	locations := s.BlobURLs(blob.Digest)
+
+	statements := []policy.StatementBuilderFunc{
+		policy.Equal(".space", space.String()),
+		policy.Equal(".content", digestutil.Format(blob.Digest)),
+	}
+	for i, u := range locations {
+		statements = append(statements, policy.Equal(fmt.Sprintf(".location[%d].url", i), u.String()))
+	}
+	statements = append(statements,
+		policy.Equal(".range.offset", 0),
+		policy.Equal(".range.length", int64(blob.Size)),
+	)
+
 	locCommitment, err := assert.Location.Delegate(
 		s.id,
 		space,
 		s.id,
-		delegation.WithPolicyBuilder(
-			policy.Equal(".space", space.String()),
-			policy.Equal(".content", digestutil.Format(blob.Digest)),
-			policy.Equal(".location[0].url", s.getBlobURL(blob.Digest).String()),
-			policy.Equal(".range.offset", 0),
-			policy.Equal(".range.length", blob.Size),
-		),
-		delegation.WithNoExpiration(),
+		delegation.WithPolicyBuilder(statements...),
+		delegation.WithExpiration(exp),
 	)
+	if err == nil {
+		err = padronucan.CheckSigned(locCommitment)
+	}
 	if err != nil {
-		log.Errorw("creating location commitment", "error", err)
-		return nil, fmt.Errorf("creating location commitment: %w", err)
+		return commitmentstore.Commitment{}, fmt.Errorf("creating location commitment: %w", err)
 	}
 
-	// TODO(vic): store and publish the location commitment
+	c := commitmentstore.Commitment{
+		Space:      space,
+		Digest:     blob.Digest,
+		Size:       blob.Size,
+		Expires:    exp,
+		Delegation: locCommitment,
+	}
+	if legacy {
+		if s.legacy == nil {
+			return commitmentstore.Commitment{}, errors.New("legacy location commitments are not supported")
+		}
+		c.Legacy, err = s.legacy(space, blob, locations, expiration)
+		if err != nil {
+			return commitmentstore.Commitment{}, fmt.Errorf("creating legacy location commitment: %w", err)
+		}
+	}
+
+	err = s.commitments.Put(ctx, c)
+	if err != nil {
+		return commitmentstore.Commitment{}, fmt.Errorf("putting location commitment: %w", err)
+	}
 
-	return locCommitment, nil
+	return c, nil
 }
 
-func (s *Service) getBlobURL(digest mh.Multihash) *url.URL {
+// BlobURL returns the preferred public URL a blob can be retrieved from.
+func (s *Service) BlobURL(digest mh.Multihash) *url.URL {
 	return s.publicURL.JoinPath("blob", digestutil.Format(digest))
 }
+
+// BlobURLs returns all the public URLs a blob can be retrieved from, starting
+// with the preferred one.
+func (s *Service) BlobURLs(digest mh.Multihash) []*url.URL {
+	urls := []*url.URL{s.BlobURL(digest)}
+	for _, u := range s.mirrorURLs {
+		urls = append(urls, u.JoinPath("blob", digestutil.Format(digest)))
+	}
+	return urls
+}
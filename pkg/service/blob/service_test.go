@@ -0,0 +1,183 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/stretchr/testify/require"
+
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+func TestMirrorURLs(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	space := testutil.RandomDID(t)
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+	cdn, err := url.Parse("https://cdn.example.com/padron")
+	require.NoError(t, err)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	blobs := blobstore.NewDsBlobstore(newDs())
+
+	var committed []*url.URL
+	legacy := func(space did.DID, blob blobsvc.Blob, locations []*url.URL, expiration time.Time) ([]byte, error) {
+		committed = locations
+		return []byte("legacy"), nil
+	}
+
+	data := []byte("testing 1, 2, 3")
+	digest, err := mh.Sum(data, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	blob := blobsvc.Blob{Digest: digest, Size: uint64(len(data))}
+	key := digestutil.Format(digest)
+
+	t.Run("public URL only", func(t *testing.T) {
+		svc := blobsvc.NewService(id, publicURL, blobs, allocs, acceptances, commitments)
+		urls := svc.BlobURLs(digest)
+		require.Len(t, urls, 1)
+		require.Equal(t, "https://node.example.com/blob/"+key, urls[0].String())
+		require.Equal(t, urls[0], svc.BlobURL(digest))
+	})
+
+	t.Run("mirrors after the public URL", func(t *testing.T) {
+		svc := blobsvc.NewService(id, publicURL, blobs, allocs, acceptances, commitments,
+			blobsvc.WithMirrorURLs(cdn),
+		)
+		urls := svc.BlobURLs(digest)
+		require.Len(t, urls, 2)
+		require.Equal(t, "https://node.example.com/blob/"+key, urls[0].String())
+		require.Equal(t, "https://cdn.example.com/padron/blob/"+key, urls[1].String())
+		// allocations are always uploaded to the node itself
+		require.Equal(t, urls[0], svc.BlobURL(digest))
+	})
+
+	t.Run("mirrors are committed to", func(t *testing.T) {
+		svc := blobsvc.NewService(id, publicURL, blobs, allocs, acceptances, commitments,
+			blobsvc.WithMirrorURLs(cdn),
+			blobsvc.WithLegacyCommitter(legacy),
+		)
+		require.NoError(t, blobs.Put(t.Context(), digest, blob.Size, bytes.NewReader(data)))
+
+		_, err := svc.AcceptLegacy(t.Context(), space, blob, testutil.RandomCID(t))
+		require.NoError(t, err)
+		require.Equal(t, svc.BlobURLs(digest), committed)
+	})
+}
+
+func TestRenewCommitments(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	space := testutil.RandomDID(t)
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	blobs := blobstore.NewDsBlobstore(newDs())
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var failing mh.Multihash
+	legacy := func(space did.DID, blob blobsvc.Blob, locations []*url.URL, expiration time.Time) ([]byte, error) {
+		if bytes.Equal(blob.Digest, failing) {
+			return nil, errors.New("boom")
+		}
+		return fmt.Appendf(nil, "%s@%d", digestutil.Format(blob.Digest), expiration.Unix()), nil
+	}
+
+	ttl := 30 * 24 * time.Hour
+	svc := blobsvc.NewService(id, publicURL, blobs, allocs, acceptances, commitments,
+		blobsvc.WithClock(func() time.Time { return now }),
+		blobsvc.WithCommitmentTTL(ttl),
+		blobsvc.WithLegacyCommitter(legacy),
+	)
+
+	put := func(t *testing.T, data string) blobsvc.Blob {
+		digest, err := mh.Sum([]byte(data), mh.SHA2_256, -1)
+		require.NoError(t, err)
+		b := blobsvc.Blob{Digest: digest, Size: uint64(len(data))}
+		require.NoError(t, blobs.Put(t.Context(), digest, b.Size, bytes.NewReader([]byte(data))))
+		return b
+	}
+
+	held := put(t, "held")
+	_, err = svc.Accept(t.Context(), space, held, testutil.RandomCID(t))
+	require.NoError(t, err)
+
+	legacyHeld := put(t, "legacy")
+	archive, err := svc.AcceptLegacy(t.Context(), space, legacyHeld, testutil.RandomCID(t))
+	require.NoError(t, err)
+
+	broken := put(t, "broken")
+	_, err = svc.AcceptLegacy(t.Context(), space, broken, testutil.RandomCID(t))
+	require.NoError(t, err)
+	failing = broken.Digest
+
+	// a commitment for a blob the node no longer holds
+	removed := testutil.RandomDigest(t)
+	prev, err := commitments.Get(t.Context(), held.Digest, space)
+	require.NoError(t, err)
+	prev.Digest = removed
+	require.NoError(t, commitments.Put(t.Context(), prev))
+
+	issued := uint64(now.Add(ttl).Unix())
+	now = now.Add(ttl - time.Hour)
+
+	err = svc.RenewCommitments(t.Context(), 24*time.Hour)
+	require.ErrorContains(t, err, "1 of 4")
+
+	renewed := uint64(now.Add(ttl).Unix())
+
+	c, err := commitments.Get(t.Context(), held.Digest, space)
+	require.NoError(t, err)
+	require.Equal(t, renewed, c.Expires)
+	require.Nil(t, c.Legacy)
+
+	c, err = commitments.Get(t.Context(), legacyHeld.Digest, space)
+	require.NoError(t, err)
+	require.Equal(t, renewed, c.Expires)
+	require.NotEqual(t, archive, c.Legacy)
+	require.Equal(t, fmt.Appendf(nil, "%s@%d", digestutil.Format(legacyHeld.Digest), renewed), c.Legacy)
+
+	c, err = commitments.Get(t.Context(), broken.Digest, space)
+	require.NoError(t, err)
+	require.Equal(t, issued, c.Expires)
+
+	_, err = commitments.Get(t.Context(), removed, space)
+	require.ErrorIs(t, err, store.ErrNotFound)
+
+	// only the commitment that failed to renew is still expiring
+	expiring, err := commitments.ListExpiring(context.Background(), uint64(now.Add(24*time.Hour).Unix()))
+	require.NoError(t, err)
+	require.Len(t, expiring, 1)
+	require.Equal(t, broken.Digest, expiring[0].Digest)
+}
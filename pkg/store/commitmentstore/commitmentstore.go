@@ -0,0 +1,236 @@
+package commitmentstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alanshaw/libracha/digestutil"
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/piri/pkg/store"
+)
+
+// Commitment is a location commitment issued for a blob held in a space.
+type Commitment struct {
+	Space  did.DID
+	Digest mh.Multihash
+	Size   uint64
+	// Expires is the expiration of the commitment in seconds since the Unix
+	// epoch.
+	Expires    uint64
+	Delegation *delegation.Delegation
+	// Legacy is the archived ucanto location commitment issued along with
+	// Delegation for blobs accepted through the legacy ucanto path, or nil.
+	Legacy []byte
+}
+
+// CommitmentStore keeps track of the latest location commitment issued for
+// each blob in each space, so that it can be renewed before it expires.
+type CommitmentStore interface {
+	// Get returns the commitment for the blob in the given space, or
+	// [store.ErrNotFound] if there is none.
+	Get(ctx context.Context, digest mh.Multihash, space did.DID) (Commitment, error)
+	// Put stores the commitment, replacing any previous commitment for the same
+	// blob and space.
+	Put(ctx context.Context, c Commitment) error
+	// Delete removes the commitment for the blob in the given space.
+	Delete(ctx context.Context, digest mh.Multihash, space did.DID) error
+	// ListExpiring returns the commitments that expire before the given time in
+	// seconds since the Unix epoch.
+	ListExpiring(ctx context.Context, before uint64) ([]Commitment, error)
+}
+
+// Commitments are stored by blob and space, and indexed by expiration under
+// expiryPrefix, so expiring ones can be listed without reading all of them.
+const expiryPrefix = "/expiry/"
+
+type DsCommitmentStore struct {
+	data datastore.Datastore
+}
+
+var _ CommitmentStore = (*DsCommitmentStore)(nil)
+
+// NewDsCommitmentStore creates a [CommitmentStore] backed by an IPFS datastore.
+func NewDsCommitmentStore(ds datastore.Datastore) (*DsCommitmentStore, error) {
+	return &DsCommitmentStore{ds}, nil
+}
+
+func (d *DsCommitmentStore) Get(ctx context.Context, digest mh.Multihash, space did.DID) (Commitment, error) {
+	value, err := d.data.Get(ctx, datastore.NewKey(encodeKey(digest, space)))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Commitment{}, store.ErrNotFound
+		}
+		return Commitment{}, fmt.Errorf("getting %s from datastore: %w", digestutil.Format(digest), err)
+	}
+	return decode(value)
+}
+
+func (d *DsCommitmentStore) Put(ctx context.Context, c Commitment) error {
+	b, err := encode(c)
+	if err != nil {
+		return fmt.Errorf("encoding data: %w", err)
+	}
+
+	prev, err := d.Get(ctx, c.Digest, c.Space)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	// index first, so a commitment is never stored without its index entry.
+	// Stale entries left by a failure are skipped when listing.
+	err = d.data.Put(ctx, expiryKey(c.Expires, c.Digest, c.Space), nil)
+	if err != nil {
+		return fmt.Errorf("writing expiry index to datastore: %w", err)
+	}
+	err = d.data.Put(ctx, datastore.NewKey(encodeKey(c.Digest, c.Space)), b)
+	if err != nil {
+		return fmt.Errorf("writing to datastore: %w", err)
+	}
+	if prev.Delegation != nil && prev.Expires != c.Expires {
+		err = d.data.Delete(ctx, expiryKey(prev.Expires, c.Digest, c.Space))
+		if err != nil {
+			return fmt.Errorf("deleting expiry index from datastore: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DsCommitmentStore) Delete(ctx context.Context, digest mh.Multihash, space did.DID) error {
+	c, err := d.Get(ctx, digest, space)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	err = d.data.Delete(ctx, datastore.NewKey(encodeKey(digest, space)))
+	if err != nil {
+		return fmt.Errorf("deleting from datastore: %w", err)
+	}
+	err = d.data.Delete(ctx, expiryKey(c.Expires, digest, space))
+	if err != nil {
+		return fmt.Errorf("deleting expiry index from datastore: %w", err)
+	}
+	return nil
+}
+
+// ListExpiring walks the expiry index in order, stopping at the first entry
+// that does not expire before the given time.
+func (d *DsCommitmentStore) ListExpiring(ctx context.Context, before uint64) ([]Commitment, error) {
+	results, err := d.data.Query(ctx, query.Query{
+		Prefix:   expiryPrefix,
+		KeysOnly: true,
+		Orders:   []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying datastore: %w", err)
+	}
+	defer results.Close()
+
+	var commitments []Commitment
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating query results: %w", entry.Error)
+		}
+		expires, key, err := parseExpiryKey(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		if expires >= before {
+			break
+		}
+
+		value, err := d.data.Get(ctx, datastore.NewKey(key))
+		if err != nil {
+			if errors.Is(err, datastore.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("getting %s from datastore: %w", key, err)
+		}
+		c, err := decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding data: %w", err)
+		}
+		// skip index entries left behind by a failed update
+		if c.Expires != expires {
+			continue
+		}
+		commitments = append(commitments, c)
+	}
+	return commitments, nil
+}
+
+type record struct {
+	Space      did.DID `json:"space"`
+	Digest     []byte  `json:"digest"`
+	Size       uint64  `json:"size"`
+	Expires    uint64  `json:"expires"`
+	Delegation []byte  `json:"delegation"`
+	Legacy     []byte  `json:"legacy,omitempty"`
+}
+
+func encode(c Commitment) ([]byte, error) {
+	dlg, err := delegation.Encode(c.Delegation)
+	if err != nil {
+		return nil, fmt.Errorf("encoding delegation: %w", err)
+	}
+	return json.Marshal(record{
+		Space:      c.Space,
+		Digest:     c.Digest,
+		Size:       c.Size,
+		Expires:    c.Expires,
+		Delegation: dlg,
+		Legacy:     c.Legacy,
+	})
+}
+
+func decode(b []byte) (Commitment, error) {
+	var r record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Commitment{}, err
+	}
+	dlg, err := delegation.Decode(r.Delegation)
+	if err != nil {
+		return Commitment{}, fmt.Errorf("decoding delegation: %w", err)
+	}
+	return Commitment{
+		Space:      r.Space,
+		Digest:     r.Digest,
+		Size:       r.Size,
+		Expires:    r.Expires,
+		Delegation: dlg,
+		Legacy:     r.Legacy,
+	}, nil
+}
+
+func encodeKey(digest mh.Multihash, space did.DID) string {
+	return fmt.Sprintf("%s/%s", digestutil.Format(digest), space.String())
+}
+
+// expiryKey is the index key of a commitment. The expiration is zero padded so
+// keys sort in expiration order.
+func expiryKey(expires uint64, digest mh.Multihash, space did.DID) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s%020d/%s", expiryPrefix, expires, encodeKey(digest, space)))
+}
+
+// parseExpiryKey returns the expiration and the commitment key in an index key.
+func parseExpiryKey(k string) (uint64, string, error) {
+	exp, key, ok := strings.Cut(strings.TrimPrefix(k, expiryPrefix), "/")
+	if !ok {
+		return 0, "", fmt.Errorf("malformed expiry index key %q", k)
+	}
+	expires, err := strconv.ParseUint(exp, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("malformed expiry index key %q: %w", k, err)
+	}
+	return expires, key, nil
+}
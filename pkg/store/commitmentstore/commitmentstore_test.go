@@ -0,0 +1,154 @@
+package commitmentstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/ipfs/go-datastore"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+func randomCommitment(t *testing.T, expires time.Time) commitmentstore.Commitment {
+	id := testutil.RandomSigner(t)
+	space := testutil.RandomDID(t)
+	exp := uint64(expires.Unix())
+
+	dlg, err := delegation.Delegate(id, id, space, "/assert/location", delegation.WithExpiration(exp))
+	require.NoError(t, err)
+
+	return commitmentstore.Commitment{
+		Space:      space,
+		Digest:     testutil.RandomDigest(t),
+		Size:       1024,
+		Expires:    exp,
+		Delegation: dlg,
+	}
+}
+
+func TestDsCommitmentStore(t *testing.T) {
+	t.Run("roundtrip", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		c := randomCommitment(t, time.Now().Add(time.Hour))
+		require.NoError(t, s.Put(t.Context(), c))
+
+		got, err := s.Get(t.Context(), c.Digest, c.Space)
+		require.NoError(t, err)
+		require.Equal(t, c.Space, got.Space)
+		require.Equal(t, c.Digest, got.Digest)
+		require.Equal(t, c.Size, got.Size)
+		require.Equal(t, c.Expires, got.Expires)
+		require.Equal(t, c.Delegation.Link(), got.Delegation.Link())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		_, err = s.Get(t.Context(), testutil.RandomDigest(t), testutil.RandomDID(t))
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		c := randomCommitment(t, time.Now().Add(time.Hour))
+		require.NoError(t, s.Put(t.Context(), c))
+		require.NoError(t, s.Delete(t.Context(), c.Digest, c.Space))
+
+		_, err = s.Get(t.Context(), c.Digest, c.Space)
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("list expiring", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		now := time.Now()
+		soon := randomCommitment(t, now.Add(time.Hour))
+		later := randomCommitment(t, now.Add(30*24*time.Hour))
+		require.NoError(t, s.Put(t.Context(), soon))
+		require.NoError(t, s.Put(t.Context(), later))
+
+		expiring, err := s.ListExpiring(t.Context(), uint64(now.Add(24*time.Hour).Unix()))
+		require.NoError(t, err)
+		require.Len(t, expiring, 1)
+		require.Equal(t, soon.Digest, expiring[0].Digest)
+	})
+
+	t.Run("list expiring in expiration order", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		now := time.Now()
+		third := randomCommitment(t, now.Add(3*time.Hour))
+		first := randomCommitment(t, now.Add(time.Hour))
+		second := randomCommitment(t, now.Add(2*time.Hour))
+		for _, c := range []commitmentstore.Commitment{third, first, second} {
+			require.NoError(t, s.Put(t.Context(), c))
+		}
+
+		expiring, err := s.ListExpiring(t.Context(), uint64(now.Add(24*time.Hour).Unix()))
+		require.NoError(t, err)
+		require.Len(t, expiring, 3)
+		require.Equal(t, first.Digest, expiring[0].Digest)
+		require.Equal(t, second.Digest, expiring[1].Digest)
+		require.Equal(t, third.Digest, expiring[2].Digest)
+	})
+
+	t.Run("replaced commitment is listed by its new expiration", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		now := time.Now()
+		c := randomCommitment(t, now.Add(time.Hour))
+		require.NoError(t, s.Put(t.Context(), c))
+
+		renewed := c
+		renewed.Expires = uint64(now.Add(30 * 24 * time.Hour).Unix())
+		require.NoError(t, s.Put(t.Context(), renewed))
+
+		expiring, err := s.ListExpiring(t.Context(), uint64(now.Add(24*time.Hour).Unix()))
+		require.NoError(t, err)
+		require.Empty(t, expiring)
+
+		expiring, err = s.ListExpiring(t.Context(), uint64(now.Add(60*24*time.Hour).Unix()))
+		require.NoError(t, err)
+		require.Len(t, expiring, 1)
+		require.Equal(t, renewed.Expires, expiring[0].Expires)
+	})
+
+	t.Run("deleted commitment is not listed", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		now := time.Now()
+		c := randomCommitment(t, now.Add(time.Hour))
+		require.NoError(t, s.Put(t.Context(), c))
+		require.NoError(t, s.Delete(t.Context(), c.Digest, c.Space))
+
+		expiring, err := s.ListExpiring(t.Context(), uint64(now.Add(24*time.Hour).Unix()))
+		require.NoError(t, err)
+		require.Empty(t, expiring)
+	})
+
+	t.Run("legacy roundtrip", func(t *testing.T) {
+		s, err := commitmentstore.NewDsCommitmentStore(datastore.NewMapDatastore())
+		require.NoError(t, err)
+
+		c := randomCommitment(t, time.Now().Add(time.Hour))
+		c.Legacy = []byte("legacy archive")
+		require.NoError(t, s.Put(t.Context(), c))
+
+		got, err := s.Get(t.Context(), c.Digest, c.Space)
+		require.NoError(t, err)
+		require.Equal(t, c.Legacy, got.Legacy)
+	})
+}
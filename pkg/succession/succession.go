@@ -0,0 +1,124 @@
+// Package succession records the rotation of node keys. When a key is
+// replaced, the node publishes a statement signed by both the retired key and
+// its successor, so clients holding delegations to the retired key can verify
+// that the new key speaks for the same node.
+package succession
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/ed25519/verifier"
+)
+
+// Path is where the node serves its key succession statements.
+const Path = "/.well-known/key-succession.json"
+
+// Statement declares that the successor key replaces the predecessor key.
+type Statement struct {
+	// ID is the DID of the node. It does not change across rotations when the
+	// node has a did:web identity.
+	ID string `json:"id"`
+	// Predecessor is the did:key of the retired key.
+	Predecessor string `json:"predecessor"`
+	// Successor is the did:key of the key replacing it.
+	Successor string `json:"successor"`
+	// ValidUntil is when the node stops accepting invocations addressed to
+	// the retired key.
+	ValidUntil time.Time `json:"validUntil"`
+	Issued     time.Time `json:"issued"`
+}
+
+// Signed is a statement together with the signatures of both keys over the
+// exact bytes of the encoded statement.
+type Signed struct {
+	Statement            json.RawMessage `json:"statement"`
+	PredecessorSignature []byte          `json:"predecessorSignature"`
+	SuccessorSignature   []byte          `json:"successorSignature"`
+}
+
+// New creates the statement of the succession of the predecessor key by the
+// successor key, in the node with the given DID, issued at the given time.
+func New(id string, predecessor, successor principal.Signer, validUntil, issued time.Time) Statement {
+	return Statement{
+		ID:          id,
+		Predecessor: predecessor.DID().String(),
+		Successor:   successor.DID().String(),
+		ValidUntil:  validUntil.UTC(),
+		Issued:      issued.UTC().Truncate(time.Second),
+	}
+}
+
+// errorSigner is implemented by signers that can report signing failures,
+// such as the key agent signer.
+type errorSigner interface {
+	TrySign(msg []byte) ([]byte, error)
+}
+
+func sign(signer principal.Signer, msg []byte) ([]byte, error) {
+	if s, ok := signer.(errorSigner); ok {
+		return s.TrySign(msg)
+	}
+	return signer.Sign(msg), nil
+}
+
+// Sign encodes the statement and signs it with both keys. The signed statement
+// is verified, so that a failure of either signer is reported rather than
+// served.
+func Sign(predecessor, successor principal.Signer, s Statement) (*Signed, error) {
+	if s.Predecessor != predecessor.DID().String() {
+		return nil, fmt.Errorf("statement predecessor %s cannot be signed by %s", s.Predecessor, predecessor.DID())
+	}
+	if s.Successor != successor.DID().String() {
+		return nil, fmt.Errorf("statement successor %s cannot be signed by %s", s.Successor, successor.DID())
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encoding succession statement: %w", err)
+	}
+	predSig, err := sign(predecessor, data)
+	if err != nil {
+		return nil, fmt.Errorf("signing with predecessor: %w", err)
+	}
+	succSig, err := sign(successor, data)
+	if err != nil {
+		return nil, fmt.Errorf("signing with successor: %w", err)
+	}
+	signed := &Signed{
+		Statement:            data,
+		PredecessorSignature: predSig,
+		SuccessorSignature:   succSig,
+	}
+	if _, err := signed.Verify(); err != nil {
+		return nil, fmt.Errorf("verifying signed statement: %w", err)
+	}
+	return signed, nil
+}
+
+// Verify checks the signatures of both keys named in the statement, and
+// returns the decoded statement.
+func (s *Signed) Verify() (Statement, error) {
+	var st Statement
+	if err := json.Unmarshal(s.Statement, &st); err != nil {
+		return Statement{}, fmt.Errorf("decoding succession statement: %w", err)
+	}
+
+	pred, err := verifier.Parse(st.Predecessor)
+	if err != nil {
+		return Statement{}, fmt.Errorf("parsing predecessor: %w", err)
+	}
+	succ, err := verifier.Parse(st.Successor)
+	if err != nil {
+		return Statement{}, fmt.Errorf("parsing successor: %w", err)
+	}
+	if !pred.Verify(s.Statement, s.PredecessorSignature) {
+		return Statement{}, errors.New("invalid predecessor signature")
+	}
+	if !succ.Verify(s.Statement, s.SuccessorSignature) {
+		return Statement{}, errors.New("invalid successor signature")
+	}
+	return st, nil
+}
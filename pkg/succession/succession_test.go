@@ -0,0 +1,85 @@
+package succession_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/succession"
+)
+
+func TestSignedStatement(t *testing.T) {
+	old, err := ed25519.Generate()
+	require.NoError(t, err)
+	current, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	issued := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	until := issued.Add(24 * time.Hour)
+	st := succession.New("did:web:example.com", old, current, until, issued)
+	signed, err := succession.Sign(old, current, st)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(signed)
+	require.NoError(t, err)
+	var fetched succession.Signed
+	require.NoError(t, json.Unmarshal(data, &fetched))
+
+	t.Run("verifies", func(t *testing.T) {
+		verified, err := fetched.Verify()
+		require.NoError(t, err)
+		require.Equal(t, old.DID().String(), verified.Predecessor)
+		require.Equal(t, current.DID().String(), verified.Successor)
+		require.Equal(t, until.Unix(), verified.ValidUntil.Unix())
+		require.Equal(t, issued, verified.Issued)
+	})
+
+	t.Run("fails when signed by other keys", func(t *testing.T) {
+		_, err := succession.Sign(current, old, st)
+		require.Error(t, err)
+
+		forged := fetched
+		forged.PredecessorSignature = current.Sign(fetched.Statement)
+		_, err = forged.Verify()
+		require.Error(t, err)
+	})
+}
+
+// brokenSigner signs with nil, like the key agent signer when the agent is
+// unreachable.
+type brokenSigner struct {
+	principal.Signer
+}
+
+func (brokenSigner) Sign([]byte) []byte { return nil }
+
+// failingSigner reports its signing failures.
+type failingSigner struct {
+	principal.Signer
+}
+
+func (failingSigner) TrySign([]byte) ([]byte, error) { return nil, errors.New("agent unreachable") }
+
+func TestSignFailures(t *testing.T) {
+	old, err := ed25519.Generate()
+	require.NoError(t, err)
+	current, err := ed25519.Generate()
+	require.NoError(t, err)
+	now := time.Now()
+	st := succession.New("did:web:example.com", old, current, now.Add(time.Hour), now)
+
+	t.Run("reports signing errors", func(t *testing.T) {
+		_, err := succession.Sign(old, failingSigner{current}, st)
+		require.ErrorContains(t, err, "agent unreachable")
+	})
+
+	t.Run("rejects missing signatures", func(t *testing.T) {
+		_, err := succession.Sign(old, brokenSigner{current}, st)
+		require.ErrorContains(t, err, "invalid successor signature")
+	})
+}
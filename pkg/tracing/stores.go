@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/alanshaw/ucantone/did"
+	mh "github.com/multiformats/go-multihash"
+	ucantodid "github.com/storacha/go-ucanto/did"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/blobstore"
+
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+)
+
+type blobstoreTracing struct {
+	blobs blobstore.Blobstore
+}
+
+// TraceBlobstore wraps a blob store, recording a span for each of its
+// operations. Filesystem access is preserved if the wrapped store supports it.
+func TraceBlobstore(blobs blobstore.Blobstore) blobstore.Blobstore {
+	if fsblobs, ok := blobs.(blobstore.FileSystemer); ok {
+		return &fsBlobstoreTracing{blobstoreTracing{blobs}, fsblobs}
+	}
+	return &blobstoreTracing{blobs}
+}
+
+func (b *blobstoreTracing) Get(ctx context.Context, digest mh.Multihash, opts ...blobstore.GetOption) (obj blobstore.Object, err error) {
+	ctx, span := Start(ctx, "blobstore.Get", Digest(digest))
+	defer func() { End(span, err) }()
+	return b.blobs.Get(ctx, digest, opts...)
+}
+
+func (b *blobstoreTracing) Put(ctx context.Context, digest mh.Multihash, size uint64, body io.Reader) (err error) {
+	ctx, span := Start(ctx, "blobstore.Put", Digest(digest), Size(size))
+	defer func() { End(span, err) }()
+	return b.blobs.Put(ctx, digest, size, body)
+}
+
+type fsBlobstoreTracing struct {
+	blobstoreTracing
+	fs blobstore.FileSystemer
+}
+
+func (b *fsBlobstoreTracing) FileSystem() http.FileSystem {
+	return b.fs.FileSystem()
+}
+
+type allocationStoreTracing struct {
+	allocs allocationstore.AllocationStore
+}
+
+// TraceAllocationStore wraps an allocation store, recording a span for each of
+// its operations.
+func TraceAllocationStore(allocs allocationstore.AllocationStore) allocationstore.AllocationStore {
+	return &allocationStoreTracing{allocs}
+}
+
+func (a *allocationStoreTracing) Get(ctx context.Context, digest mh.Multihash, space ucantodid.DID) (alloc allocation.Allocation, err error) {
+	ctx, span := Start(ctx, "allocationstore.Get", Digest(digest), Space(space.String()))
+	defer func() { End(span, err) }()
+	return a.allocs.Get(ctx, digest, space)
+}
+
+func (a *allocationStoreTracing) List(ctx context.Context, digest mh.Multihash, opts ...allocationstore.ListOption) (allocs []allocation.Allocation, err error) {
+	ctx, span := Start(ctx, "allocationstore.List", Digest(digest))
+	defer func() { End(span, err) }()
+	return a.allocs.List(ctx, digest, opts...)
+}
+
+func (a *allocationStoreTracing) Put(ctx context.Context, alloc allocation.Allocation) (err error) {
+	ctx, span := Start(ctx, "allocationstore.Put", Digest(alloc.Blob.Digest), Space(alloc.Space.String()))
+	defer func() { End(span, err) }()
+	return a.allocs.Put(ctx, alloc)
+}
+
+type acceptanceStoreTracing struct {
+	acceptances acceptancestore.AcceptanceStore
+}
+
+// TraceAcceptanceStore wraps an acceptance store, recording a span for each of
+// its operations.
+func TraceAcceptanceStore(acceptances acceptancestore.AcceptanceStore) acceptancestore.AcceptanceStore {
+	return &acceptanceStoreTracing{acceptances}
+}
+
+func (a *acceptanceStoreTracing) Get(ctx context.Context, digest mh.Multihash, space ucantodid.DID) (acc acceptance.Acceptance, err error) {
+	ctx, span := Start(ctx, "acceptancestore.Get", Digest(digest), Space(space.String()))
+	defer func() { End(span, err) }()
+	return a.acceptances.Get(ctx, digest, space)
+}
+
+func (a *acceptanceStoreTracing) List(ctx context.Context, digest mh.Multihash, opts ...acceptancestore.ListOption) (accs []acceptance.Acceptance, err error) {
+	ctx, span := Start(ctx, "acceptancestore.List", Digest(digest))
+	defer func() { End(span, err) }()
+	return a.acceptances.List(ctx, digest, opts...)
+}
+
+func (a *acceptanceStoreTracing) Put(ctx context.Context, acc acceptance.Acceptance) (err error) {
+	ctx, span := Start(ctx, "acceptancestore.Put", Digest(acc.Blob.Digest), Space(acc.Space.String()))
+	defer func() { End(span, err) }()
+	return a.acceptances.Put(ctx, acc)
+}
+
+type commitmentStoreTracing struct {
+	commitments commitmentstore.CommitmentStore
+}
+
+// TraceCommitmentStore wraps a location commitment store, recording a span for
+// each of its operations.
+func TraceCommitmentStore(commitments commitmentstore.CommitmentStore) commitmentstore.CommitmentStore {
+	return &commitmentStoreTracing{commitments}
+}
+
+func (c *commitmentStoreTracing) Get(ctx context.Context, digest mh.Multihash, space did.DID) (cm commitmentstore.Commitment, err error) {
+	ctx, span := Start(ctx, "commitmentstore.Get", Digest(digest), Space(space.String()))
+	defer func() { End(span, err) }()
+	return c.commitments.Get(ctx, digest, space)
+}
+
+func (c *commitmentStoreTracing) Put(ctx context.Context, cm commitmentstore.Commitment) (err error) {
+	ctx, span := Start(ctx, "commitmentstore.Put", Digest(cm.Digest), Space(cm.Space.String()))
+	defer func() { End(span, err) }()
+	return c.commitments.Put(ctx, cm)
+}
+
+func (c *commitmentStoreTracing) Delete(ctx context.Context, digest mh.Multihash, space did.DID) (err error) {
+	ctx, span := Start(ctx, "commitmentstore.Delete", Digest(digest), Space(space.String()))
+	defer func() { End(span, err) }()
+	return c.commitments.Delete(ctx, digest, space)
+}
+
+func (c *commitmentStoreTracing) ListExpiring(ctx context.Context, before uint64) (cms []commitmentstore.Commitment, err error) {
+	ctx, span := Start(ctx, "commitmentstore.ListExpiring")
+	defer func() { End(span, err) }()
+	return c.commitments.ListExpiring(ctx, before)
+}
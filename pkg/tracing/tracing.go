@@ -0,0 +1,75 @@
+// Package tracing provides OpenTelemetry tracing helpers and instrumentation
+// for the padrón storage node.
+package tracing
+
+import (
+	"context"
+
+	"github.com/alanshaw/libracha/digestutil"
+	mh "github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/volmedo/padron"
+
+// tracer delegates to the global tracer provider, so spans are exported once
+// a provider has been configured.
+var tracer = otel.Tracer(instrumentationName)
+
+// Attribute keys used across padrón spans.
+const (
+	SpaceKey      = attribute.Key("padron.space")
+	DigestKey     = attribute.Key("padron.blob.digest")
+	SizeKey       = attribute.Key("padron.blob.size")
+	CommandKey    = attribute.Key("ucan.command")
+	InvocationKey = attribute.Key("ucan.invocation")
+	OutcomeKey    = attribute.Key("ucan.outcome")
+)
+
+// Start starts a span with the given name and attributes, as a child of any
+// span in the context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Space returns an attribute for the DID of a space.
+func Space(space string) attribute.KeyValue {
+	return SpaceKey.String(space)
+}
+
+// Digest returns an attribute for the digest of a blob.
+func Digest(digest mh.Multihash) attribute.KeyValue {
+	return DigestKey.String(digestutil.Format(digest))
+}
+
+// Size returns an attribute for the size of a blob.
+func Size(size uint64) attribute.KeyValue {
+	return SizeKey.Int64(int64(size))
+}
+
+// Command returns an attribute for a UCAN command.
+func Command(cmd string) attribute.KeyValue {
+	return CommandKey.String(cmd)
+}
+
+// Invocation returns an attribute for the CID of a UCAN invocation.
+func Invocation(link string) attribute.KeyValue {
+	return InvocationKey.String(link)
+}
+
+// Outcome returns an attribute for the outcome of a UCAN invocation.
+func Outcome(outcome string) attribute.KeyValue {
+	return OutcomeKey.String(outcome)
+}
@@ -5,28 +5,35 @@ import (
 
 	"github.com/alanshaw/libracha/capabilities"
 	blobcap "github.com/alanshaw/libracha/capabilities/blob"
+	"github.com/alanshaw/libracha/digestutil"
 	"github.com/alanshaw/ucantone/execution/bindexec"
+	"github.com/alanshaw/ucantone/ucan/container"
 	logging "github.com/ipfs/go-log/v2"
 
+	"github.com/volmedo/padron/pkg/requestid"
 	blobsvc "github.com/volmedo/padron/pkg/service/blob"
 	"github.com/volmedo/padron/pkg/ucan"
 )
 
 var log = logging.Logger("ucan/blob")
 
-const maxUploadSize = 127 * (1 << 25)
-
 func NewBlobAllocateHandler(svc *blobsvc.Service) *ucan.Handler {
 	return &ucan.Handler{
 		Capability: blobcap.Allocate,
+		Arguments:  &blobcap.AllocateArguments{},
 		Handler: bindexec.NewHandler(
 			func(req *bindexec.Request[*blobcap.AllocateArguments]) (*bindexec.Response[*blobcap.AllocateOK], error) {
 				args := req.Task().BindArguments()
-				log.Debugf("%+v", args)
+				requestid.Logger(req.Context(), log).Debugf("%+v", args)
 
 				// enforce max upload size requirements
-				if args.Blob.Size > maxUploadSize {
-					return nil, fmt.Errorf("blob size %d exceeds maximum upload size of %d bytes", args.Blob.Size, maxUploadSize)
+				if args.Blob.Size > blobsvc.MaxUploadSize {
+					return nil, fmt.Errorf("blob size %d exceeds maximum upload size of %d bytes", args.Blob.Size, blobsvc.MaxUploadSize)
+				}
+
+				// only accept digests the node can verify on upload
+				if !blobsvc.DigestSupported(args.Blob.Digest) {
+					return nil, fmt.Errorf("blob digest %s is not of a supported multihash type", digestutil.Format(args.Blob.Digest))
 				}
 
 				size, address, err := svc.Allocate(
@@ -70,10 +77,11 @@ func NewBlobAllocateHandler(svc *blobsvc.Service) *ucan.Handler {
 func NewBlobAcceptHandler(svc *blobsvc.Service) *ucan.Handler {
 	return &ucan.Handler{
 		Capability: blobcap.Accept,
+		Arguments:  &blobcap.AcceptArguments{},
 		Handler: bindexec.NewHandler(
 			func(req *bindexec.Request[*blobcap.AcceptArguments]) (*bindexec.Response[*blobcap.AcceptOK], error) {
 				args := req.Task().BindArguments()
-				log.Debugf("%+v", args)
+				requestid.Logger(req.Context(), log).Debugf("%+v", args)
 
 				locCommitment, err := svc.Accept(
 					req.Context(),
@@ -91,8 +99,15 @@ func NewBlobAcceptHandler(svc *blobsvc.Service) *ucan.Handler {
 				ok := &blobcap.AcceptOK{
 					Site: locCommitment.Link(),
 				}
-				
-				return bindexec.NewResponse(bindexec.WithSuccess(ok))
+
+				// attach the location commitment to the response, so the caller can
+				// resolve the site link without an additional round trip
+				meta := container.New(container.WithDelegations(locCommitment))
+
+				return bindexec.NewResponse(
+					bindexec.WithSuccess(ok),
+					bindexec.WithMetadata[*blobcap.AcceptOK](meta),
+				)
 			},
 		),
 	}
@@ -0,0 +1,148 @@
+package blob_test
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+
+	blobcap "github.com/alanshaw/libracha/capabilities/blob"
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/stretchr/testify/require"
+
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/ucan"
+	blobucan "github.com/volmedo/padron/pkg/ucan/blob"
+	"github.com/volmedo/padron/pkg/ucan/server"
+)
+
+func TestBlobHandlers(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+	space, err := ed25519.Generate()
+	require.NoError(t, err)
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	blobs := blobstore.NewDsBlobstore(newDs())
+
+	svc := blobsvc.NewService(service, publicURL, blobs, allocs, acceptances, commitments,
+		blobsvc.WithClock(func() time.Time { return now }),
+	)
+	d := server.NewDispatcher(service.Verifier())
+	for _, h := range []*ucan.Handler{blobucan.NewBlobAllocateHandler(svc), blobucan.NewBlobAcceptHandler(svc)} {
+		d.Handle(h.Capability, h.Handler)
+	}
+
+	data := []byte("testing 1, 2, 3")
+	digest, err := mh.Sum(data, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	blob := blobcap.Blob{Digest: digest, Size: uint64(len(data))}
+
+	t.Run("allocate", func(t *testing.T) {
+		inv, err := blobcap.Allocate.Invoke(space, space, &blobcap.AllocateArguments{
+			Blob:  blob,
+			Cause: testutil.RandomCID(t),
+		}, invocation.WithAudience(service))
+		require.NoError(t, err)
+
+		ok := blobcap.AllocateOK{}
+		execute(t, d, inv, &ok)
+		require.Equal(t, blob.Size, ok.Size)
+		require.NotNil(t, ok.Address)
+		require.Equal(t, svc.BlobURL(digest).String(), ok.Address.URL.URL().String())
+		require.Equal(t, now.Add(blobsvc.AllocationTTL).Unix(), ok.Address.Expires.Time().Unix())
+	})
+
+	t.Run("rejects oversized allocation", func(t *testing.T) {
+		inv, err := blobcap.Allocate.Invoke(space, space, &blobcap.AllocateArguments{
+			Blob:  blobcap.Blob{Digest: digest, Size: blobsvc.MaxUploadSize + 1},
+			Cause: testutil.RandomCID(t),
+		}, invocation.WithAudience(service))
+		require.NoError(t, err)
+
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		_, x := result.Unwrap(resp.Result())
+		require.NotNil(t, x)
+	})
+
+	t.Run("rejects unsupported digest", func(t *testing.T) {
+		unsupported, err := mh.Sum(data, mh.SHA2_512, -1)
+		require.NoError(t, err)
+		inv, err := blobcap.Allocate.Invoke(space, space, &blobcap.AllocateArguments{
+			Blob:  blobcap.Blob{Digest: unsupported, Size: blob.Size},
+			Cause: testutil.RandomCID(t),
+		}, invocation.WithAudience(service))
+		require.NoError(t, err)
+
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		_, x := result.Unwrap(resp.Result())
+		require.NotNil(t, x)
+	})
+
+	t.Run("accept before upload fails", func(t *testing.T) {
+		inv, err := blobcap.Accept.Invoke(space, space, &blobcap.AcceptArguments{Blob: blob}, invocation.WithAudience(service))
+		require.NoError(t, err)
+
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		_, x := result.Unwrap(resp.Result())
+		require.NotNil(t, x)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		require.NoError(t, blobs.Put(t.Context(), digest, blob.Size, bytes.NewReader(data)))
+
+		inv, err := blobcap.Accept.Invoke(space, space, &blobcap.AcceptArguments{Blob: blob}, invocation.WithAudience(service))
+		require.NoError(t, err)
+
+		ok := blobcap.AcceptOK{}
+		resp := execute(t, d, inv, &ok)
+
+		c, err := commitments.Get(t.Context(), digest, space.DID())
+		require.NoError(t, err)
+		require.Equal(t, c.Delegation.Link(), ok.Site)
+
+		// the location commitment is attached to the response metadata
+		require.NotNil(t, resp.Metadata())
+		dlgs := resp.Metadata().Delegations()
+		require.Len(t, dlgs, 1)
+		require.Equal(t, ok.Site, dlgs[0].Link())
+		require.Equal(t, service.DID(), dlgs[0].Issuer().DID())
+	})
+}
+
+// execute executes the invocation and binds its successful result to ok.
+func execute(t *testing.T, d *server.Dispatcher, inv *invocation.Invocation, ok dagcbor.Unmarshaler) execution.Response {
+	t.Helper()
+	resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+	require.NoError(t, err)
+
+	o, x := result.Unwrap(resp.Result())
+	require.Nil(t, x)
+	require.NoError(t, datamodel.Rebind(datamodel.NewAny(o), ok))
+	return resp
+}
@@ -8,4 +8,7 @@ import (
 type Handler struct {
 	Capability validator.Capability
 	Handler    execution.HandlerFunc
+	// Arguments is a value of the type the handler binds invocation arguments
+	// to. It is used to describe the arguments in the capability manifest.
+	Arguments any
 }
@@ -0,0 +1,168 @@
+// Package manifest describes the UCAN commands a node handles, and the
+// policies it applies, in a document signed by the node identity so that
+// clients can cache it and verify where it came from. The document is carried
+// in the metadata of a UCAN delegation the node issues to itself, and served
+// in a UCAN container.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+
+	padronucan "github.com/volmedo/padron/pkg/ucan"
+)
+
+// Manifest lists the commands handled by a node and its policies.
+type Manifest struct {
+	// Issuer is the DID of the node that issued the manifest.
+	Issuer   string    `json:"issuer"`
+	Issued   time.Time `json:"issued"`
+	Commands []Command `json:"commands"`
+	// LegacyCommands are the abilities of the legacy ucanto (UCAN 0.x)
+	// invocations the node handles.
+	LegacyCommands []string `json:"legacyCommands,omitempty"`
+	Policies       Policies `json:"policies"`
+}
+
+// Command describes a UCAN command handled by the node.
+type Command struct {
+	Command string `json:"command"`
+	// Policy is the base policy of the capability, in the DAG-JSON encoding of
+	// the UCAN Policy Language.
+	Policy    json.RawMessage `json:"policy,omitempty"`
+	Arguments *Schema         `json:"arguments,omitempty"`
+}
+
+// Policies are the limits the node applies to blobs.
+type Policies struct {
+	MaxBlobSize uint64 `json:"maxBlobSize"`
+	// AllocationTTL is how long, in seconds, an allocation waits for the blob
+	// to be uploaded.
+	AllocationTTL int64 `json:"allocationTtl"`
+	// CommitmentTTL is the lifetime, in seconds, of location commitments.
+	CommitmentTTL  int64    `json:"commitmentTtl"`
+	MultihashCodes []string `json:"multihashCodes"`
+}
+
+// DelegationCommand is the command of the delegation carrying a manifest. It
+// grants nothing, the delegation only serves as a signed envelope.
+const DelegationCommand = ucan.Command("/ucan/manifest")
+
+// metadataKey is the key of the encoded manifest in the delegation metadata.
+const metadataKey = "manifest"
+
+type dagJSONMarshaler interface {
+	MarshalDagJSON(w io.Writer) error
+}
+
+// New creates a manifest describing the given handlers.
+func New(issuer principal.Signer, handlers []*padronucan.Handler, policies Policies) (Manifest, error) {
+	m := Manifest{
+		Issuer:   issuer.DID().String(),
+		Issued:   time.Now().UTC().Truncate(time.Second),
+		Commands: make([]Command, 0, len(handlers)),
+		Policies: policies,
+	}
+
+	for _, h := range handlers {
+		cmd := Command{Command: string(h.Capability.Command())}
+		if p, ok := h.Capability.Policy().(dagJSONMarshaler); ok {
+			var buf bytes.Buffer
+			if err := p.MarshalDagJSON(&buf); err != nil {
+				return Manifest{}, fmt.Errorf("encoding policy of %s: %w", cmd.Command, err)
+			}
+			cmd.Policy = buf.Bytes()
+		}
+		if h.Arguments != nil {
+			cmd.Arguments = SchemaOf(h.Arguments)
+		}
+		m.Commands = append(m.Commands, cmd)
+	}
+
+	return m, nil
+}
+
+// Sign encodes the manifest into a delegation signed by the given identity,
+// which must be the manifest issuer, and returns it in an encoded container.
+func Sign(signer principal.Signer, m Manifest) ([]byte, error) {
+	if m.Issuer != signer.DID().String() {
+		return nil, fmt.Errorf("manifest issued by %s cannot be signed by %s", m.Issuer, signer.DID())
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	dlg, err := delegation.Delegate(
+		signer,
+		signer,
+		signer,
+		DelegationCommand,
+		delegation.WithNoExpiration(),
+		delegation.WithMetadata(ipld.Map{metadataKey: data}),
+	)
+	if err == nil {
+		err = padronucan.CheckSigned(dlg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating manifest delegation: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := container.New(container.WithDelegations(dlg)).MarshalCBOR(&buf); err != nil {
+		return nil, fmt.Errorf("encoding manifest container: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Verify decodes an encoded manifest container, checks that the manifest was
+// issued and signed by the given principal, and returns the manifest.
+func Verify(data []byte, verifier principal.Verifier) (Manifest, error) {
+	ct := container.New()
+	if err := ct.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+		return Manifest{}, fmt.Errorf("decoding manifest container: %w", err)
+	}
+
+	var dlg ucan.Delegation
+	for _, d := range ct.Delegations() {
+		if d.Command() == DelegationCommand {
+			dlg = d
+			break
+		}
+	}
+	if dlg == nil {
+		return Manifest{}, errors.New("manifest delegation not found")
+	}
+	if dlg.Issuer().DID() != verifier.DID() {
+		return Manifest{}, fmt.Errorf("manifest issued by %s, not %s", dlg.Issuer().DID(), verifier.DID())
+	}
+	ok, err := delegation.VerifySignature(dlg, verifier)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("verifying manifest signature: %w", err)
+	}
+	if !ok {
+		return Manifest{}, errors.New("invalid manifest signature")
+	}
+
+	encoded, ok := dlg.Metadata()[metadataKey].([]byte)
+	if !ok {
+		return Manifest{}, errors.New("manifest missing from delegation metadata")
+	}
+	var m Manifest
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		return Manifest{}, fmt.Errorf("decoding manifest: %w", err)
+	}
+	if m.Issuer != verifier.DID().String() {
+		return Manifest{}, fmt.Errorf("manifest issued by %s, not %s", m.Issuer, verifier.DID())
+	}
+	return m, nil
+}
@@ -0,0 +1,104 @@
+package manifest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/ucan/manifest"
+)
+
+func TestSignedManifest(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	m, err := manifest.New(id, nil, manifest.Policies{
+		MaxBlobSize:    1024,
+		MultihashCodes: []string{"sha2-256"},
+	})
+	require.NoError(t, err)
+	m.LegacyCommands = []string{"blob/accept", "blob/allocate"}
+
+	signed, err := manifest.Sign(id, m)
+	require.NoError(t, err)
+
+	t.Run("verifies with issuer key", func(t *testing.T) {
+		verified, err := manifest.Verify(signed, id.Verifier())
+		require.NoError(t, err)
+		require.Equal(t, id.DID().String(), verified.Issuer)
+		require.Equal(t, uint64(1024), verified.Policies.MaxBlobSize)
+		require.Equal(t, []string{"blob/accept", "blob/allocate"}, verified.LegacyCommands)
+	})
+
+	t.Run("is a UCAN container", func(t *testing.T) {
+		ct := container.New()
+		require.NoError(t, ct.UnmarshalCBOR(bytes.NewReader(signed)))
+		require.Len(t, ct.Delegations(), 1)
+
+		dlg := ct.Delegations()[0]
+		require.Equal(t, manifest.DelegationCommand, dlg.Command())
+		require.Equal(t, id.DID(), dlg.Issuer().DID())
+	})
+
+	t.Run("fails with another key", func(t *testing.T) {
+		other, err := ed25519.Generate()
+		require.NoError(t, err)
+
+		_, err = manifest.Verify(signed, other.Verifier())
+		require.Error(t, err)
+	})
+
+	t.Run("fails when tampered", func(t *testing.T) {
+		// same length, so the envelope still decodes
+		tampered := bytes.Replace(signed, []byte(`"maxBlobSize":1024`), []byte(`"maxBlobSize":9999`), 1)
+		require.NotEqual(t, signed, tampered)
+
+		_, err := manifest.Verify(tampered, id.Verifier())
+		require.Error(t, err)
+	})
+
+	t.Run("fails on junk", func(t *testing.T) {
+		_, err := manifest.Verify([]byte(`{"manifest":{}}`), id.Verifier())
+		require.Error(t, err)
+	})
+}
+
+func TestSchemaOf(t *testing.T) {
+	type blob struct {
+		Digest mh.Multihash
+		Size   uint64
+	}
+	type args struct {
+		Space did.DID
+		Blob  blob
+		Cause *cid.Cid `cborgen:"cause,omitempty"`
+		Tags  []string
+	}
+
+	s := manifest.SchemaOf(&args{})
+	require.Equal(t, "map", s.Kind)
+	require.True(t, s.Nullable)
+	require.Len(t, s.Fields, 4)
+
+	require.Equal(t, "Space", s.Fields[0].Name)
+	require.Equal(t, "string", s.Fields[0].Kind)
+
+	require.Equal(t, "Blob", s.Fields[1].Name)
+	require.Equal(t, "map", s.Fields[1].Kind)
+	require.Equal(t, "bytes", s.Fields[1].Fields[0].Kind)
+	require.Equal(t, "int", s.Fields[1].Fields[1].Kind)
+
+	require.Equal(t, "cause", s.Fields[2].Name)
+	require.Equal(t, "link", s.Fields[2].Kind)
+	require.True(t, s.Fields[2].Optional)
+	require.True(t, s.Fields[2].Nullable)
+
+	require.Equal(t, "list", s.Fields[3].Kind)
+	require.Equal(t, "string", s.Fields[3].Elem.Kind)
+}
@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+)
+
+// Schema describes the shape of a value in terms of IPLD data model kinds.
+type Schema struct {
+	Kind     string  `json:"kind"`
+	Nullable bool    `json:"nullable,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+	Elem     *Schema `json:"elem,omitempty"`
+}
+
+// Field is a field of a map with a known set of keys.
+type Field struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional,omitempty"`
+	Schema
+}
+
+var (
+	cidType           = reflect.TypeFor[cid.Cid]()
+	stringerType      = reflect.TypeFor[fmt.Stringer]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// SchemaOf describes the type of the given value. Structs are described as
+// maps keyed by their CBOR field names.
+func SchemaOf(v any) *Schema {
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if t == nil {
+		return &Schema{Kind: "null"}
+	}
+	if t.Kind() == reflect.Pointer {
+		s := schemaOf(t.Elem(), seen)
+		s.Nullable = true
+		return s
+	}
+	if t == cidType {
+		return &Schema{Kind: "link"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Kind: "bool"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Kind: "int"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Kind: "float"}
+	case reflect.String:
+		return &Schema{Kind: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Kind: "bytes"}
+		}
+		return &Schema{Kind: "list", Elem: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Kind: "map", Elem: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		return structSchema(t, seen)
+	default:
+		return &Schema{Kind: "any"}
+	}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if seen[t] {
+		return &Schema{Kind: "map"}
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	s := &Schema{Kind: "map"}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("cborgen"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Fields = append(s.Fields, Field{
+			Name:     name,
+			Optional: strings.Contains(opts, "omitempty"),
+			Schema:   *schemaOf(f.Type, seen),
+		})
+	}
+
+	// opaque types, such as DIDs, are encoded as their string form
+	if len(s.Fields) == 0 {
+		if t.Implements(textMarshalerType) || t.Implements(stringerType) ||
+			reflect.PointerTo(t).Implements(textMarshalerType) {
+			return &Schema{Kind: "string"}
+		}
+		return &Schema{Kind: "any"}
+	}
+	return s
+}
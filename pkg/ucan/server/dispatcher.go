@@ -0,0 +1,265 @@
+package server
+
+import (
+	"time"
+
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/execution/dispatcher"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator"
+	verrs "github.com/alanshaw/ucantone/validator/errors"
+	"github.com/ipfs/go-cid"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/tracing"
+)
+
+type handler struct {
+	Func       execution.HandlerFunc
+	Capability validator.Capability
+}
+
+// audience is an additional principal invocations can be addressed to.
+type audience struct {
+	key        ucan.Verifier
+	validUntil time.Time
+}
+
+// Dispatcher executes UCAN invocations by dispatching them to registered
+// handlers. It mirrors the ucantone dispatcher, but checks the time bounds of
+// invocations and proofs against a configurable clock with a tolerated skew,
+// and accepts invocations addressed to additional audiences.
+type Dispatcher struct {
+	authority  ucan.Verifier
+	audiences  []audience
+	handlers   map[ucan.Command]handler
+	now        func() time.Time
+	skew       time.Duration
+	validation validation
+}
+
+// NewDispatcher creates an invocation executor that validates and dispatches
+// UCAN invocations to registered handlers.
+func NewDispatcher(authority ucan.Verifier, options ...DispatcherOption) *Dispatcher {
+	cfg := dispatcherConfig{now: time.Now, validation: defaultValidation()}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return &Dispatcher{
+		authority:  authority,
+		audiences:  cfg.audiences,
+		handlers:   map[ucan.Command]handler{},
+		now:        cfg.now,
+		skew:       cfg.skew,
+		validation: cfg.validation,
+	}
+}
+
+func (d *Dispatcher) Handle(capability validator.Capability, fn execution.HandlerFunc) {
+	d.handlers[capability.Command()] = handler{Func: fn, Capability: capability}
+}
+
+func (d *Dispatcher) Execute(req execution.Request) (execution.Response, error) {
+	v, err := d.Validate(req)
+	if err != nil {
+		d.observe(req, nil, err)
+		return nil, err
+	}
+	return d.Run(v)
+}
+
+// Validated is an invocation checked by [Dispatcher.Validate], either
+// authorized or with the failure to report for it.
+type Validated struct {
+	req     execution.Request
+	handler handler
+	failure execution.Response
+}
+
+// Authorized reports whether the invocation is addressed to the dispatcher,
+// has a handler and is authorized by its proofs, so its issuer is verified.
+func (v *Validated) Authorized() bool {
+	return v.failure == nil
+}
+
+// Validate checks the invocation of req without executing it, so requests can
+// be admitted, e.g. by rate limits on their verified issuer, before any of
+// their invocations is run with [Dispatcher.Run].
+func (d *Dispatcher) Validate(req execution.Request) (*Validated, error) {
+	aud := req.Invocation().Audience()
+	if aud == nil {
+		aud = req.Invocation().Subject()
+	}
+	authority, ok := d.authorityFor(aud)
+	if !ok {
+		return d.reject(req, execution.NewInvalidAudienceError(d.authority, aud))
+	}
+
+	cmd := req.Invocation().Command()
+	handler, ok := d.handlers[cmd]
+	if !ok {
+		return d.reject(req, dispatcher.NewHandlerNotFoundError(cmd))
+	}
+
+	if err := d.access(req, authority, handler.Capability); err != nil {
+		return d.reject(req, err)
+	}
+	return &Validated{req: req, handler: handler}, nil
+}
+
+func (d *Dispatcher) reject(req execution.Request, err error) (*Validated, error) {
+	failure, rerr := execution.NewResponse(execution.WithFailure(err))
+	if rerr != nil {
+		return nil, rerr
+	}
+	return &Validated{req: req, failure: failure}, nil
+}
+
+// Run executes a validated invocation, or returns its failure if it was not
+// authorized.
+func (d *Dispatcher) Run(v *Validated) (execution.Response, error) {
+	res, err := d.run(v)
+	d.observe(v.req, res, err)
+	return res, err
+}
+
+func (d *Dispatcher) run(v *Validated) (execution.Response, error) {
+	if !v.Authorized() {
+		return v.failure, nil
+	}
+	cmd := v.req.Invocation().Command()
+	res, err := v.handler.Func(v.req)
+	if err != nil {
+		return execution.NewResponse(
+			execution.WithFailure(execution.NewHandlerExecutionError(cmd, err)),
+		)
+	}
+	return res, nil
+}
+
+// observe records the outcome of an invocation.
+func (d *Dispatcher) observe(req execution.Request, res execution.Response, err error) {
+	command := string(req.Invocation().Command())
+	if _, ok := d.handlers[req.Invocation().Command()]; !ok {
+		command = metrics.UnknownCommand
+	}
+	metrics.ObserveInvocation(command, outcome(res, err))
+}
+
+// authorityFor returns the key verifying invocations addressed to aud, if the
+// dispatcher accepts them.
+func (d *Dispatcher) authorityFor(aud ucan.Principal) (ucan.Verifier, bool) {
+	if aud.DID() == d.authority.DID() {
+		return d.authority, true
+	}
+	now := d.now()
+	for _, a := range d.audiences {
+		if aud.DID() != a.key.DID() {
+			continue
+		}
+		if !a.validUntil.IsZero() && !now.Add(-d.skew).Before(a.validUntil) {
+			return nil, false
+		}
+		return a.key, true
+	}
+	return nil, false
+}
+
+// outcome classifies the result of an execution as "ok" or "error".
+func outcome(res execution.Response, err error) string {
+	if err != nil || res == nil {
+		return "error"
+	}
+	return result.MatchResultR1(
+		res.Result(),
+		func(ipld.Any) string { return "ok" },
+		func(ipld.Any) string { return "error" },
+	)
+}
+
+// access is equivalent to [validator.Access], except for the time bounds
+// checks, which use the dispatcher clock and skew.
+func (d *Dispatcher) access(req execution.Request, authority ucan.Verifier, capability validator.Capability) (err error) {
+	ctx, span := tracing.Start(req.Context(), "ucan.Validate")
+	defer func() { tracing.End(span, err) }()
+
+	inv := req.Invocation()
+
+	provided := map[cid.Cid]ucan.Delegation{}
+	if req.Metadata() != nil {
+		for _, p := range req.Metadata().Delegations() {
+			provided[p.Link()] = p
+		}
+	}
+
+	v := d.validation
+	proofs, err := validator.ResolveProofs(ctx, provided, v.resolveProof, inv.Proofs())
+	if err != nil {
+		return err
+	}
+
+	if err := d.validateTimeBounds(inv, proofs); err != nil {
+		return err
+	}
+
+	err = validator.VerifyAuthorization(
+		ctx,
+		authority,
+		v.canIssue,
+		v.parsePrincipal,
+		v.resolveDIDKey,
+		inv,
+		proofs,
+	)
+	if err != nil {
+		return err
+	}
+
+	match, err := capability.Match(inv, proofs)
+	if err != nil {
+		return err
+	}
+	return v.validateAuthorization(ctx, validator.Authorization{
+		Invocation: inv,
+		Task:       match.Task,
+		Proofs:     match.Proofs,
+	})
+}
+
+func (d *Dispatcher) validateTimeBounds(inv ucan.Invocation, proofs map[cid.Cid]ucan.Delegation) error {
+	now := d.now()
+	if isExpired(inv, now, d.skew) {
+		return verrs.NewExpiredError(inv)
+	}
+	for _, p := range proofs {
+		if isExpired(p, now, d.skew) {
+			return verrs.NewExpiredError(p)
+		}
+		if isTooEarly(p, now, d.skew) {
+			return verrs.NewTooEarlyError(p)
+		}
+	}
+	return nil
+}
+
+// isExpired reports whether the token expired before now, allowing for the
+// passed clock skew.
+func isExpired(token ucan.Token, now time.Time, skew time.Duration) bool {
+	exp := token.Expiration()
+	if exp == nil {
+		return false
+	}
+	return int64(*exp) <= now.Add(-skew).Unix()
+}
+
+// isTooEarly reports whether the delegation is not yet active at now, allowing
+// for the passed clock skew.
+func isTooEarly(dlg ucan.Delegation, now time.Time, skew time.Duration) bool {
+	nbf := dlg.NotBefore()
+	if nbf == nil || *nbf == 0 {
+		return false
+	}
+	return now.Add(skew).Unix() <= int64(*nbf)
+}
@@ -0,0 +1,210 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/validator"
+	verrs "github.com/alanshaw/ucantone/validator/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/ucan/server"
+)
+
+func TestDispatcherTimeBounds(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	echo := func(req execution.Request) (execution.Response, error) {
+		return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+	}
+
+	// invocation expired 10 seconds before the (fixed) current time
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(service),
+		invocation.WithExpiration(ucan.UTCUnixTimestamp(now.Add(-10*time.Second).Unix())),
+	)
+	require.NoError(t, err)
+
+	t.Run("rejects expired invocation without skew", func(t *testing.T) {
+		d := server.NewDispatcher(service.Verifier(), server.WithClock(clock))
+		d.Handle(testutil.TestEchoCapability, echo)
+
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(resp.Result())
+		require.Nil(t, o)
+		require.NotNil(t, x)
+		require.Equal(t, verrs.ExpiredErrorName, x.(ipld.Map)["name"])
+	})
+
+	t.Run("accepts expired invocation within skew", func(t *testing.T) {
+		d := server.NewDispatcher(service.Verifier(), server.WithClock(clock), server.WithClockSkew(time.Minute))
+		d.Handle(testutil.TestEchoCapability, echo)
+
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(resp.Result())
+		require.Nil(t, x)
+		require.Equal(t, "echo!", o.(ipld.Map)["message"])
+	})
+}
+
+func TestDispatcherAudiences(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	retired := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	echo := func(req execution.Request) (execution.Response, error) {
+		return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+	}
+
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(retired),
+	)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, d *server.Dispatcher) (ipld.Any, ipld.Any) {
+		d.Handle(testutil.TestEchoCapability, echo)
+		resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		return result.Unwrap(resp.Result())
+	}
+
+	t.Run("rejects unknown audience", func(t *testing.T) {
+		_, x := execute(t, server.NewDispatcher(service.Verifier(), server.WithClock(clock)))
+		require.Equal(t, execution.InvalidAudienceErrorName, x.(ipld.Map)["name"])
+	})
+
+	t.Run("accepts retired key before it expires", func(t *testing.T) {
+		o, x := execute(t, server.NewDispatcher(service.Verifier(),
+			server.WithClock(clock),
+			server.WithAudience(retired.Verifier(), now.Add(time.Hour)),
+		))
+		require.Nil(t, x)
+		require.Equal(t, "echo!", o.(ipld.Map)["message"])
+	})
+
+	t.Run("rejects retired key after it expires", func(t *testing.T) {
+		_, x := execute(t, server.NewDispatcher(service.Verifier(),
+			server.WithClock(clock),
+			server.WithAudience(retired.Verifier(), now.Add(-time.Hour)),
+		))
+		require.Equal(t, execution.InvalidAudienceErrorName, x.(ipld.Map)["name"])
+	})
+}
+
+func TestDispatcherValidationOptions(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(service),
+	)
+	require.NoError(t, err)
+
+	var validated []ucan.Invocation
+	d := server.NewDispatcher(service.Verifier(), server.WithAuthorizationValidator(
+		func(ctx context.Context, auth validator.Authorization) error {
+			validated = append(validated, auth.Invocation)
+			return errors.New("revoked")
+		},
+	))
+	d.Handle(testutil.TestEchoCapability, func(req execution.Request) (execution.Response, error) {
+		t.Fatal("handler called for an invocation rejected by the authorization validator")
+		return nil, nil
+	})
+
+	resp, err := d.Execute(execution.NewRequest(t.Context(), inv))
+	require.NoError(t, err)
+
+	_, x := result.Unwrap(resp.Result())
+	require.NotNil(t, x)
+	require.Len(t, validated, 1)
+	require.Equal(t, inv.Link(), validated[0].Link())
+}
+
+func TestDispatcherMetrics(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(service),
+	)
+	require.NoError(t, err)
+	command := string(testutil.TestEchoCapability.Command())
+
+	t.Run("counts handled commands", func(t *testing.T) {
+		d := server.NewDispatcher(service.Verifier())
+		d.Handle(testutil.TestEchoCapability, func(req execution.Request) (execution.Response, error) {
+			return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+		})
+
+		before := invocations(t, command, "ok")
+		_, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		require.Equal(t, before+1, invocations(t, command, "ok"))
+	})
+
+	t.Run("counts unhandled commands as unknown", func(t *testing.T) {
+		d := server.NewDispatcher(service.Verifier())
+
+		before := invocations(t, metrics.UnknownCommand, "error")
+		unhandled := invocations(t, command, "error")
+		_, err := d.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+		require.Equal(t, before+1, invocations(t, metrics.UnknownCommand, "error"))
+		require.Equal(t, unhandled, invocations(t, command, "error"))
+	})
+}
+
+// invocations returns the number of invocations of the command with the
+// outcome counted so far.
+func invocations(t *testing.T, command, outcome string) float64 {
+	t.Helper()
+	mfs, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "padron_ucan_invocations_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["command"] == command && labels["outcome"] == outcome {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+// Package server provides a UCAN HTTP server built on ucantone, with
+// validation settings that the upstream server does not expose.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	"github.com/alanshaw/ucantone/validator"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/tracing"
+	padronucan "github.com/volmedo/padron/pkg/ucan"
+)
+
+type HTTPServer struct {
+	id       principal.Signer
+	executor *Dispatcher
+	codec    transport.InboundCodec[*http.Request, *http.Response]
+	limiter  Limiter
+}
+
+// NewHTTP creates a new server capable of handling UCAN invocations over HTTP.
+func NewHTTP(id principal.Signer, options ...HTTPOption) *HTTPServer {
+	cfg := httpServerConfig{
+		codec: transport.DefaultHTTPInboundCodec,
+	}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return &HTTPServer{
+		id:       id,
+		codec:    cfg.codec,
+		executor: NewDispatcher(id.Verifier(), cfg.dispatcherOpts...),
+		limiter:  cfg.limiter,
+	}
+}
+
+func (s *HTTPServer) Handle(capability validator.Capability, fn execution.HandlerFunc) {
+	s.executor.Handle(capability, fn)
+}
+
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.RoundTrip(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("request body larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("handling request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	resp.Body.Close()
+}
+
+// RoundTrip unpacks and executes an incoming request, returning the response.
+// Every invocation is validated before any is executed, so a request rejected
+// by the rate limits has no effect.
+func (s *HTTPServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	reqContainer, err := s.codec.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding request: %w", err)
+	}
+
+	pending := make([]*pendingInvocation, 0, len(reqContainer.Invocations()))
+	for _, inv := range reqContainer.Invocations() {
+		p, err := s.validate(r.Context(), inv, reqContainer)
+		if err != nil {
+			endAll(pending, err)
+			return nil, fmt.Errorf("validating task %s: %w", inv.Task().Link(), err)
+		}
+		pending = append(pending, p)
+	}
+
+	if resp := s.limit(pending); resp != nil {
+		endAll(pending, errRateLimited)
+		return resp, nil
+	}
+
+	var invocations []ucan.Invocation
+	var delegations []ucan.Delegation
+	var receipts []ucan.Receipt
+	for i, p := range pending {
+		res, err := s.run(p)
+		if err != nil {
+			endAll(pending[i+1:], err)
+			// executor only returns an error when result or metadata cannot be set,
+			// which is likely a developer error.
+			return nil, fmt.Errorf("executing task %s: %w", p.inv.Task().Link(), err)
+		}
+
+		opts := []receipt.Option{receipt.WithCause(p.inv.Link())}
+		// record the request ID in the receipt, so it can be correlated with
+		// the node logs
+		if id := requestid.FromContext(r.Context()); id != "" {
+			opts = append(opts, receipt.WithMetadata(ipld.Map{"requestId": id}))
+		}
+		rcpt, err := receipt.Issue(
+			s.id,
+			p.inv.Task().Link(),
+			res.Result(),
+			opts...,
+		)
+		if err == nil {
+			err = padronucan.CheckSigned(rcpt)
+		}
+		if err != nil {
+			endAll(pending[i+1:], err)
+			return nil, fmt.Errorf("issuing receipt for task %q: %w", p.inv.Task().Link(), err)
+		}
+		receipts = append(receipts, rcpt)
+
+		if res.Metadata() != nil {
+			invocations = append(invocations, res.Metadata().Invocations()...)
+			delegations = append(delegations, res.Metadata().Delegations()...)
+			receipts = append(receipts, res.Metadata().Receipts()...)
+		}
+	}
+
+	respContainer := container.New(
+		container.WithInvocations(invocations...),
+		container.WithDelegations(delegations...),
+		container.WithReceipts(receipts...),
+	)
+
+	resp, err := s.codec.Encode(respContainer)
+	if err != nil {
+		return nil, fmt.Errorf("encoding response container: %w", err)
+	}
+
+	return resp, nil
+}
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// limit takes a token for the issuer of every authorized invocation, and
+// returns a 429 response if any of them is over its limit, or a 413 response
+// if any has more invocations than its burst, which would never be allowed.
+// Issuers are only verified once invocations are authorized, so invocations
+// failing validation are not charged, and cannot use up the tokens of the
+// issuer they claim. No token is taken for a rejected request.
+func (s *HTTPServer) limit(pending []*pendingInvocation) *http.Response {
+	if s.limiter == nil {
+		return nil
+	}
+	var issuers []string
+	for _, p := range pending {
+		if p.validated.Authorized() {
+			issuers = append(issuers, p.inv.Issuer().DID().String())
+		}
+	}
+	if len(issuers) == 0 {
+		return nil
+	}
+	err := s.limiter.AllowAll(issuers)
+	if err == nil {
+		return nil
+	}
+	header := http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}}
+	status := http.StatusTooManyRequests
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		header.Set("Retry-After", ratelimit.RetryAfter(limitErr.RetryAfter))
+	} else {
+		status = http.StatusRequestEntityTooLarge
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(err.Error() + "\n")),
+	}
+}
+
+// pendingInvocation is an invocation from the request container that was
+// validated but not yet run, within a span that covers validation and
+// handling.
+type pendingInvocation struct {
+	inv       ucan.Invocation
+	validated *Validated
+	span      trace.Span
+}
+
+// validate validates a single invocation from the request container.
+func (s *HTTPServer) validate(ctx context.Context, inv ucan.Invocation, reqContainer ucan.Container) (*pendingInvocation, error) {
+	ctx, span := tracing.Start(ctx, "ucan.Execute",
+		tracing.Command(string(inv.Command())),
+		tracing.Invocation(inv.Link().String()),
+		tracing.Space(inv.Subject().DID().String()),
+	)
+
+	req := execution.NewRequest(
+		ctx,
+		inv,
+		execution.WithInvocations(reqContainer.Invocations()...),
+		execution.WithDelegations(reqContainer.Delegations()...),
+		execution.WithReceipts(reqContainer.Receipts()...),
+	)
+	validated, err := s.executor.Validate(req)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	return &pendingInvocation{inv: inv, validated: validated, span: span}, nil
+}
+
+// run executes a validated invocation and ends its span.
+func (s *HTTPServer) run(p *pendingInvocation) (_ execution.Response, err error) {
+	defer func() { tracing.End(p.span, err) }()
+
+	res, err := s.executor.Run(p.validated)
+	p.span.SetAttributes(tracing.Outcome(outcome(res, err)))
+	return res, err
+}
+
+// endAll ends the spans of invocations that will not be run.
+func endAll(pending []*pendingInvocation, err error) {
+	for _, p := range pending {
+		tracing.End(p.span, err)
+	}
+}
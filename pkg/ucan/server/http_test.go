@@ -0,0 +1,171 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/ucan/server"
+)
+
+func TestHTTPServerRequestID(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+
+	srv := server.NewHTTP(service)
+	srv.Handle(testutil.TestEchoCapability, func(req execution.Request) (execution.Response, error) {
+		return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+	})
+
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(service),
+	)
+	require.NoError(t, err)
+
+	roundTrip := func(t *testing.T, id string) ucan.Receipt {
+		req, err := transport.DefaultHTTPOutboundCodec.Encode(container.New(container.WithInvocations(inv)))
+		require.NoError(t, err)
+		ctx := t.Context()
+		if id != "" {
+			ctx = requestid.WithID(ctx, id)
+		}
+
+		resp, err := srv.RoundTrip(req.WithContext(ctx))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		ct, err := transport.DefaultHTTPOutboundCodec.Decode(resp)
+		require.NoError(t, err)
+
+		for _, rcpt := range ct.Receipts() {
+			if rcpt.Ran() == inv.Task().Link() {
+				return rcpt
+			}
+		}
+		t.Fatal("receipt not found")
+		return nil
+	}
+
+	t.Run("records the request ID in the receipt", func(t *testing.T) {
+		rcpt := roundTrip(t, "abc123")
+		require.NotNil(t, rcpt.Metadata())
+		require.Equal(t, "abc123", rcpt.Metadata()["requestId"])
+	})
+
+	t.Run("no metadata without a request ID", func(t *testing.T) {
+		rcpt := roundTrip(t, "")
+		require.NotContains(t, rcpt.Metadata(), "requestId")
+	})
+}
+
+func TestHTTPServerBodyLimit(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+
+	srv := server.NewHTTP(service)
+	srv.Handle(testutil.TestEchoCapability, func(req execution.Request) (execution.Response, error) {
+		return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+	})
+
+	inv, err := testutil.TestEchoCapability.Invoke(
+		alice,
+		alice,
+		datamodel.Map{"message": "echo!"},
+		invocation.WithAudience(service),
+	)
+	require.NoError(t, err)
+	req, err := transport.DefaultHTTPOutboundCodec.Encode(container.New(container.WithInvocations(inv)))
+	require.NoError(t, err)
+
+	// a body of unknown length cut by http.MaxBytesReader while decoding
+	rec := httptest.NewRecorder()
+	req.ContentLength = -1
+	req.Body = http.MaxBytesReader(rec, req.Body, 16)
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+// impostor signs with its own key, but claims to be someone else.
+type impostor struct {
+	ucan.Signer
+	did did.DID
+}
+
+func (i impostor) DID() did.DID { return i.did }
+
+func TestHTTPServerRateLimit(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	mallory := testutil.RandomSigner(t)
+
+	var executed int
+	srv := server.NewHTTP(service, server.WithLimiter(ratelimit.New(0.001, 2)))
+	srv.Handle(testutil.TestEchoCapability, func(req execution.Request) (execution.Response, error) {
+		executed++
+		return execution.NewResponse(execution.WithSuccess(req.Invocation().Arguments()))
+	})
+
+	invoke := func(t *testing.T, issuer ucan.Signer, message string) ucan.Invocation {
+		inv, err := testutil.TestEchoCapability.Invoke(
+			issuer,
+			alice,
+			datamodel.Map{"message": message},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+		return inv
+	}
+	roundTrip := func(t *testing.T, invs ...ucan.Invocation) *http.Response {
+		req, err := transport.DefaultHTTPOutboundCodec.Encode(container.New(container.WithInvocations(invs...)))
+		require.NoError(t, err)
+		resp, err := srv.RoundTrip(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("does not charge invocations failing validation", func(t *testing.T) {
+		forged := invoke(t, impostor{mallory, alice.DID()}, "forged")
+		for range 3 {
+			require.Equal(t, http.StatusOK, roundTrip(t, forged).StatusCode)
+		}
+		require.Zero(t, executed)
+	})
+
+	t.Run("rejects requests over the burst without executing them", func(t *testing.T) {
+		resp := roundTrip(t, invoke(t, alice, "1"), invoke(t, alice, "2"), invoke(t, alice, "3"))
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Retry-After"))
+		require.Zero(t, executed)
+	})
+
+	t.Run("rejects requests over the limit without executing them", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, roundTrip(t, invoke(t, alice, "1")).StatusCode)
+		require.Equal(t, 1, executed)
+
+		resp := roundTrip(t, invoke(t, alice, "2"), invoke(t, alice, "3"))
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get("Retry-After"))
+		require.Equal(t, 1, executed)
+	})
+
+	t.Run("does not charge rejected requests", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, roundTrip(t, invoke(t, alice, "2")).StatusCode)
+		require.Equal(t, 2, executed)
+
+		require.Equal(t, http.StatusTooManyRequests, roundTrip(t, invoke(t, alice, "3")).StatusCode)
+	})
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator"
+)
+
+// DispatcherOption is an option configuring a [Dispatcher].
+type DispatcherOption func(cfg *dispatcherConfig)
+
+type dispatcherConfig struct {
+	now        func() time.Time
+	skew       time.Duration
+	audiences  []audience
+	validation validation
+}
+
+// validation holds the hooks of the validator. They mirror the
+// [validator.Option] ones, which cannot be passed through as is because the
+// config they apply to is not exported.
+type validation struct {
+	canIssue              validator.CanIssueFunc
+	parsePrincipal        validator.PrincipalParserFunc
+	resolveProof          validator.ProofResolverFunc
+	resolveDIDKey         validator.DIDResolverFunc
+	validateAuthorization validator.ValidateAuthorizationFunc
+}
+
+func defaultValidation() validation {
+	return validation{
+		canIssue:              validator.IsSelfIssued,
+		parsePrincipal:        validator.ParsePrincipal,
+		resolveProof:          validator.ProofUnavailable,
+		resolveDIDKey:         validator.FailDIDKeyResolution,
+		validateAuthorization: validator.NopValidateAuthorization,
+	}
+}
+
+// WithClock sets the function used to obtain the current time when checking
+// the time bounds of invocations and proofs. Defaults to [time.Now].
+func WithClock(now func() time.Time) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		if now != nil {
+			cfg.now = now
+		}
+	}
+}
+
+// WithClockSkew sets the clock drift tolerated when checking the time bounds
+// of invocations and proofs.
+func WithClockSkew(skew time.Duration) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.skew = skew
+	}
+}
+
+// WithAudience accepts invocations addressed to another principal, verified
+// with the given key, until the given time. A zero time never expires. It is
+// used to keep accepting invocations addressed to retired keys of the node.
+func WithAudience(key ucan.Verifier, validUntil time.Time) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.audiences = append(cfg.audiences, audience{key: key, validUntil: validUntil})
+	}
+}
+
+// WithCanIssue is the dispatcher equivalent of [validator.WithCanIssue].
+func WithCanIssue(canIssue validator.CanIssueFunc) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.validation.canIssue = canIssue
+	}
+}
+
+// WithPrincipalParser is the dispatcher equivalent of
+// [validator.WithPrincipalParser].
+func WithPrincipalParser(parsePrincipal validator.PrincipalParserFunc) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.validation.parsePrincipal = parsePrincipal
+	}
+}
+
+// WithProofResolver is the dispatcher equivalent of
+// [validator.WithProofResolver]. It resolves proofs not included in the
+// request.
+func WithProofResolver(resolveProof validator.ProofResolverFunc) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.validation.resolveProof = resolveProof
+	}
+}
+
+// WithDIDResolver is the dispatcher equivalent of [validator.WithDIDResolver].
+func WithDIDResolver(resolveDIDKey validator.DIDResolverFunc) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.validation.resolveDIDKey = resolveDIDKey
+	}
+}
+
+// WithAuthorizationValidator is the dispatcher equivalent of
+// [validator.WithAuthorizationValidator]. It is called once an invocation is
+// validated, e.g. to check its delegations have not been revoked.
+func WithAuthorizationValidator(validateAuthorization validator.ValidateAuthorizationFunc) DispatcherOption {
+	return func(cfg *dispatcherConfig) {
+		cfg.validation.validateAuthorization = validateAuthorization
+	}
+}
+
+// HTTPOption is an option configuring a UCAN HTTP server.
+type HTTPOption func(cfg *httpServerConfig)
+
+type httpServerConfig struct {
+	codec          transport.InboundCodec[*http.Request, *http.Response]
+	dispatcherOpts []DispatcherOption
+	limiter        Limiter
+}
+
+// Limiter limits the rate of invocations by key.
+type Limiter interface {
+	// AllowAll takes a token for every key, only if all of them have one. It
+	// returns a [*ratelimit.LimitError] otherwise, or a
+	// [*ratelimit.BurstError] if a key is repeated more times than it could
+	// ever be allowed.
+	AllowAll(keys []string) error
+}
+
+// WithLimiter limits the rate of invocations of each issuer. Requests with an
+// authorized invocation over the limit are rejected with 429 Too Many
+// Requests, before any invocation in them is executed, and those with more
+// invocations from an issuer than its burst with 413 Content Too Large.
+// Invocations failing validation are not counted, as their issuer is not
+// verified.
+func WithLimiter(limiter Limiter) HTTPOption {
+	return func(cfg *httpServerConfig) {
+		cfg.limiter = limiter
+	}
+}
+
+func WithHTTPCodec(codec transport.InboundCodec[*http.Request, *http.Response]) HTTPOption {
+	return func(cfg *httpServerConfig) {
+		cfg.codec = codec
+	}
+}
+
+func WithDispatcherOptions(options ...DispatcherOption) HTTPOption {
+	return func(cfg *httpServerConfig) {
+		cfg.dispatcherOpts = append(cfg.dispatcherOpts, options...)
+	}
+}
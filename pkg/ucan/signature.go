@@ -0,0 +1,21 @@
+package ucan
+
+import (
+	"errors"
+
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// ErrNotSigned is returned for tokens issued with an empty signature. Signers
+// that cannot report errors, like the key agent signer, sign with nil when
+// they fail.
+var ErrNotSigned = errors.New("the token has no signature, signing failed")
+
+// CheckSigned returns [ErrNotSigned] if the token was issued with an empty
+// signature.
+func CheckSigned(t ucan.Token) error {
+	if t.Signature() == nil || len(t.Signature().Bytes()) == 0 {
+		return ErrNotSigned
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package ucanto
+
+import (
+	"context"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Audience is an alternative audience of a ucanto server, accepted until
+// ValidUntil, or forever if it is zero.
+type Audience struct {
+	DID        did.DID
+	ValidUntil time.Time
+}
+
+// ExpireAudiences stops the server from accepting alternative audiences
+// configured with [server.WithAlternativeAudiences] once they expire, which
+// ucanto does not support itself.
+func ExpireAudiences(srv server.ServerView[server.Service], now func() time.Time, audiences ...Audience) server.ServerView[server.Service] {
+	if now == nil {
+		now = time.Now
+	}
+	until := map[did.DID]time.Time{}
+	for _, a := range audiences {
+		if !a.ValidUntil.IsZero() {
+			until[a.DID] = a.ValidUntil
+		}
+	}
+	return &expiringServer{ServerView: srv, now: now, until: until}
+}
+
+type expiringServer struct {
+	server.ServerView[server.Service]
+	now   func() time.Time
+	until map[did.DID]time.Time
+}
+
+func (s *expiringServer) Context() server.InvocationContext {
+	return expiringContext{s.ServerView.Context(), s}
+}
+
+func (s *expiringServer) Run(ctx context.Context, inv server.ServiceInvocation) (receipt.AnyReceipt, error) {
+	return server.Run(ctx, s, inv)
+}
+
+type expiringContext struct {
+	server.InvocationContext
+	srv *expiringServer
+}
+
+func (c expiringContext) AlternativeAudiences() []ucan.Principal {
+	now := c.srv.now()
+	var audiences []ucan.Principal
+	for _, a := range c.InvocationContext.AlternativeAudiences() {
+		if until, ok := c.srv.until[a.DID()]; ok && !now.Before(until) {
+			continue
+		}
+		audiences = append(audiences, a)
+	}
+	return audiences
+}
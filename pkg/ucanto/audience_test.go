@@ -0,0 +1,120 @@
+package ucanto_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	fdm "github.com/storacha/go-ucanto/core/result/failure/datamodel"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/server"
+	carrequest "github.com/storacha/go-ucanto/transport/car/request"
+	carresponse "github.com/storacha/go-ucanto/transport/car/response"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/stretchr/testify/require"
+
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/ucanto"
+	ucantoblob "github.com/volmedo/padron/pkg/ucanto/blob"
+)
+
+func TestExpireAudiences(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	service, err := ucanto.ToSigner(id)
+	require.NoError(t, err)
+	retiredKey, err := ed25519.Generate()
+	require.NoError(t, err)
+	retiredSigner, err := ucanto.ToSigner(retiredKey)
+	require.NoError(t, err)
+	retired, err := did.Parse(retiredKey.DID().String())
+	require.NoError(t, err)
+	spaceID, err := ed25519.Generate()
+	require.NoError(t, err)
+	space, err := did.Parse(spaceID.DID().String())
+	require.NoError(t, err)
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	svc := blobsvc.NewService(id, publicURL, blobstore.NewDsBlobstore(newDs()), allocs, acceptances, commitments)
+
+	srv, err := server.NewServer(service,
+		ucantoblob.NewBlobAllocateMethod(svc),
+		server.WithAlternativeAudiences(retired),
+	)
+	require.NoError(t, err)
+	validUntil := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := validUntil.Add(-time.Hour)
+	expiring := ucanto.ExpireAudiences(srv, func() time.Time { return now }, ucanto.Audience{DID: retired, ValidUntil: validUntil})
+
+	digest, err := mh.Sum([]byte("testing 1, 2, 3"), mh.SHA2_256, -1)
+	require.NoError(t, err)
+	b := types.Blob{Digest: digest, Size: 15}
+	// an allocation on the retired key, as delegated to clients before the
+	// rotation
+	inv, err := blob.Allocate.Invoke(retiredSigner, retired, retired.String(), blob.AllocateCaveats{
+		Space: space,
+		Blob:  b,
+		Cause: cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest)},
+	})
+	require.NoError(t, err)
+
+	run := func(t *testing.T) receipt.AnyReceipt {
+		msg, err := message.Build([]invocation.Invocation{inv}, nil)
+		require.NoError(t, err)
+		req, err := carrequest.Encode(msg)
+		require.NoError(t, err)
+		res, err := ucanto.Handle(t.Context(), expiring, req)
+		require.NoError(t, err)
+		out, err := carresponse.Decode(res)
+		require.NoError(t, err)
+		link, ok := out.Get(inv.Link())
+		require.True(t, ok)
+		rcpt, ok, err := out.Receipt(link)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return rcpt
+	}
+
+	t.Run("accepts the audience until it expires", func(t *testing.T) {
+		typed, err := receipt.Rebind[blob.AllocateOk, fdm.FailureModel](run(t), blob.AllocateOkType(), fdm.FailureType(), types.Converters...)
+		require.NoError(t, err)
+		ok, x := result.Unwrap(typed.Out())
+		require.Zero(t, x)
+		require.Equal(t, b.Size, ok.Size)
+		require.NotNil(t, ok.Address)
+	})
+
+	t.Run("rejects the audience once it expires", func(t *testing.T) {
+		now = validUntil
+		_, x := result.Unwrap(run(t).Out())
+		require.NotNil(t, x)
+		nd, err := x.LookupByString("name")
+		require.NoError(t, err)
+		name, err := nd.AsString()
+		require.NoError(t, err)
+		require.Equal(t, "InvalidAudienceError", name)
+	})
+}
@@ -0,0 +1,199 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	ucantodid "github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/piri/pkg/store"
+
+	"github.com/volmedo/padron/pkg/requestid"
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/ucanto"
+)
+
+var log = logging.Logger("ucanto/blob")
+
+// NewBlobAllocateMethod creates a ucanto service method for legacy
+// `blob/allocate` invocations, backed by the blob service.
+func NewBlobAllocateMethod(svc *blobsvc.Service) server.Option {
+	return server.WithServiceMethod(
+		blob.AllocateAbility,
+		ucanto.Instrument(blob.AllocateAbility, server.Provide(
+			blob.Allocate,
+			func(ctx context.Context, cap ucan.Capability[blob.AllocateCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[blob.AllocateOk, failure.IPLDBuilderFailure], fx.Effects, error) {
+				// only service principal can perform an allocation
+				if !isNode(cap.With(), iCtx) {
+					return result.Error[blob.AllocateOk, failure.IPLDBuilderFailure](NewUnsupportedCapabilityError(cap)), nil, nil
+				}
+
+				nb := cap.Nb()
+				requestid.Logger(ctx, log).Debugf("%+v", nb)
+
+				// enforce max upload size requirements
+				if nb.Blob.Size > blobsvc.MaxUploadSize {
+					return result.Error[blob.AllocateOk, failure.IPLDBuilderFailure](NewBlobSizeLimitExceededError(nb.Blob.Size, blobsvc.MaxUploadSize)), nil, nil
+				}
+
+				// only accept digests the node can verify on upload
+				if !blobsvc.DigestSupported(nb.Blob.Digest) {
+					return result.Error[blob.AllocateOk, failure.IPLDBuilderFailure](NewUnsupportedDigestError(nb.Blob.Digest)), nil, nil
+				}
+
+				space, err := did.Parse(nb.Space.String())
+				if err != nil {
+					return nil, nil, fmt.Errorf("parsing space DID: %w", err)
+				}
+
+				size, address, err := svc.Allocate(
+					ctx,
+					space,
+					blobsvc.Blob{
+						Digest: nb.Blob.Digest,
+						Size:   nb.Blob.Size,
+					},
+					toCID(inv.Link()),
+				)
+				if err != nil {
+					return nil, nil, fmt.Errorf("allocation failed: %w", err)
+				}
+
+				ok := blob.AllocateOk{Size: size}
+				if address != nil {
+					ok.Address = &blob.Address{
+						URL:     *address.URL,
+						Headers: address.Headers,
+						Expires: uint64(address.Expires.Unix()),
+					}
+				}
+
+				return result.Ok[blob.AllocateOk, failure.IPLDBuilderFailure](ok), nil, nil
+			},
+		)),
+	)
+}
+
+// isNode reports whether the resource of a capability is the node: its
+// identity, or one of the audiences it still accepts, such as the key of a
+// did:web node or a retired key that has not expired.
+func isNode(with string, iCtx server.InvocationContext) bool {
+	if with == iCtx.ID().DID().String() {
+		return true
+	}
+	for _, a := range iCtx.AlternativeAudiences() {
+		if with == a.DID().String() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBlobAcceptMethod creates a ucanto service method for legacy `blob/accept`
+// invocations, backed by the blob service. The location commitment is issued
+// as a ucanto delegation, so that legacy clients can consume it. The blob
+// service must be configured with [NewLocationCommitter].
+func NewBlobAcceptMethod(svc *blobsvc.Service) server.Option {
+	return server.WithServiceMethod(
+		blob.AcceptAbility,
+		ucanto.Instrument(blob.AcceptAbility, server.Provide(
+			blob.Accept,
+			func(ctx context.Context, cap ucan.Capability[blob.AcceptCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[blob.AcceptOk, failure.IPLDBuilderFailure], fx.Effects, error) {
+				// only service principal can accept a blob
+				if !isNode(cap.With(), iCtx) {
+					return result.Error[blob.AcceptOk, failure.IPLDBuilderFailure](NewUnsupportedCapabilityError(cap)), nil, nil
+				}
+
+				nb := cap.Nb()
+				requestid.Logger(ctx, log).Debugf("%+v", nb)
+
+				space, err := did.Parse(nb.Space.String())
+				if err != nil {
+					return nil, nil, fmt.Errorf("parsing space DID: %w", err)
+				}
+
+				b := blobsvc.Blob{
+					Digest: nb.Blob.Digest,
+					Size:   nb.Blob.Size,
+				}
+				archive, err := svc.AcceptLegacy(ctx, space, b, toCID(inv.Link()))
+				if err != nil {
+					if errors.Is(err, store.ErrNotFound) {
+						return result.Error[blob.AcceptOk, failure.IPLDBuilderFailure](NewAllocatedMemoryNotWrittenError()), nil, nil
+					}
+					return nil, nil, fmt.Errorf("accept failed: %w", err)
+				}
+
+				claim, err := delegation.Extract(archive)
+				if err != nil {
+					return nil, nil, fmt.Errorf("extracting location commitment: %w", err)
+				}
+
+				ok := blob.AcceptOk{Site: claim.Link()}
+				effects := fx.NewEffects(fx.WithFork(fx.FromInvocation(claim)))
+
+				return result.Ok[blob.AcceptOk, failure.IPLDBuilderFailure](ok), effects, nil
+			},
+		)),
+	)
+}
+
+func toCID(link ucan.Link) cid.Cid {
+	if cl, ok := link.(cidlink.Link); ok {
+		return cl.Cid
+	}
+	return cid.MustParse(link.String())
+}
+
+// NewLocationCommitter returns a function issuing legacy ucanto location
+// commitments signed by id, for the blob service to store and renew.
+func NewLocationCommitter(id principal.Signer) blobsvc.LegacyCommitFunc {
+	return func(space did.DID, b blobsvc.Blob, locations []*url.URL, expiration time.Time) ([]byte, error) {
+		sp, err := ucantodid.Parse(space.String())
+		if err != nil {
+			return nil, fmt.Errorf("parsing space DID: %w", err)
+		}
+
+		var locs []url.URL
+		for _, u := range locations {
+			locs = append(locs, *u)
+		}
+
+		byteRange := assert.Range{Offset: 0, Length: &b.Size}
+		claim, err := assert.Location.Delegate(
+			id,
+			sp,
+			id.DID().String(),
+			assert.LocationCaveats{
+				Space:    sp,
+				Content:  types.FromHash(b.Digest),
+				Location: locs,
+				Range:    &byteRange,
+			},
+			delegation.WithExpiration(int(expiration.Unix())),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("creating location commitment: %w", err)
+		}
+
+		return io.ReadAll(delegation.Archive(claim))
+	}
+}
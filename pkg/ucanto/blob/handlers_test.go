@@ -0,0 +1,228 @@
+package blob_test
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+
+	ucantonedid "github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	fdm "github.com/storacha/go-ucanto/core/result/failure/datamodel"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	blobsvc "github.com/volmedo/padron/pkg/service/blob"
+	"github.com/volmedo/padron/pkg/store/commitmentstore"
+	"github.com/volmedo/padron/pkg/tracing"
+	"github.com/volmedo/padron/pkg/ucanto"
+	ucantoblob "github.com/volmedo/padron/pkg/ucanto/blob"
+)
+
+func TestBlobMethods(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	service, err := ucanto.ToSigner(id)
+	require.NoError(t, err)
+	spaceID, err := ed25519.Generate()
+	require.NoError(t, err)
+	space, err := did.Parse(spaceID.DID().String())
+	require.NoError(t, err)
+	publicURL, err := url.Parse("https://node.example.com")
+	require.NoError(t, err)
+
+	newDs := func() datastore.Batching { return sync.MutexWrap(datastore.NewMapDatastore()) }
+	allocs, err := allocationstore.NewDsAllocationStore(newDs())
+	require.NoError(t, err)
+	acceptances, err := acceptancestore.NewDsAcceptanceStore(newDs())
+	require.NoError(t, err)
+	commitments, err := commitmentstore.NewDsCommitmentStore(newDs())
+	require.NoError(t, err)
+	blobs := blobstore.NewDsBlobstore(newDs())
+
+	svc := blobsvc.NewService(id, publicURL, blobs, allocs, acceptances, commitments,
+		blobsvc.WithLegacyCommitter(ucantoblob.NewLocationCommitter(service)),
+	)
+	srv, err := server.NewServer(service,
+		ucantoblob.NewBlobAllocateMethod(svc),
+		ucantoblob.NewBlobAcceptMethod(svc),
+	)
+	require.NoError(t, err)
+
+	data := []byte("testing 1, 2, 3")
+	digest, err := mh.Sum(data, mh.SHA2_256, -1)
+	require.NoError(t, err)
+	b := types.Blob{Digest: digest, Size: uint64(len(data))}
+	// stands in for the links to the causing invocations
+	link := cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest)}
+
+	t.Run("allocate", func(t *testing.T) {
+		inv, err := blob.Allocate.Invoke(service, service, service.DID().String(), blob.AllocateCaveats{
+			Space: space,
+			Blob:  b,
+			Cause: link,
+		})
+		require.NoError(t, err)
+
+		rcpt, err := srv.Run(t.Context(), inv)
+		require.NoError(t, err)
+		typed, err := receipt.Rebind[blob.AllocateOk, fdm.FailureModel](rcpt, blob.AllocateOkType(), fdm.FailureType(), types.Converters...)
+		require.NoError(t, err)
+
+		ok, x := result.Unwrap(typed.Out())
+		require.Zero(t, x)
+		require.Equal(t, b.Size, ok.Size)
+		require.NotNil(t, ok.Address)
+		require.Equal(t, svc.BlobURL(digest).String(), ok.Address.URL.String())
+		require.Greater(t, ok.Address.Expires, uint64(time.Now().Unix()))
+	})
+
+	t.Run("instruments invocations", func(t *testing.T) {
+		inv, err := blob.Allocate.Invoke(service, service, service.DID().String(), blob.AllocateCaveats{
+			Space: space,
+			Blob:  b,
+			Cause: link,
+		})
+		require.NoError(t, err)
+
+		before := invocations(t, blob.AllocateAbility, "ok")
+		_, err = srv.Run(t.Context(), inv)
+		require.NoError(t, err)
+		require.Equal(t, before+1, invocations(t, blob.AllocateAbility, "ok"))
+
+		var execute, allocate sdktrace.ReadOnlySpan
+		for _, s := range spans.Ended() {
+			switch s.Name() {
+			case "ucanto.Execute":
+				execute = s
+			case "blob.Allocate":
+				allocate = s
+			}
+		}
+		require.NotNil(t, execute)
+		require.NotNil(t, allocate)
+		require.Contains(t, execute.Attributes(), tracing.Command(blob.AllocateAbility))
+		require.Contains(t, execute.Attributes(), tracing.Invocation(inv.Link().String()))
+		require.Contains(t, execute.Attributes(), tracing.Outcome("ok"))
+		require.Equal(t, execute.SpanContext().SpanID(), allocate.Parent().SpanID())
+	})
+
+	t.Run("rejects allocation for another service", func(t *testing.T) {
+		other, err := ed25519.Generate()
+		require.NoError(t, err)
+		inv, err := blob.Allocate.Invoke(service, service, other.DID().String(), blob.AllocateCaveats{
+			Space: space,
+			Blob:  b,
+			Cause: link,
+		})
+		require.NoError(t, err)
+
+		rcpt, err := srv.Run(t.Context(), inv)
+		require.NoError(t, err)
+		_, x := result.Unwrap(rcpt.Out())
+		require.NotNil(t, x)
+	})
+
+	t.Run("rejects unsupported digest", func(t *testing.T) {
+		unsupported, err := mh.Sum(data, mh.SHA2_512, -1)
+		require.NoError(t, err)
+		inv, err := blob.Allocate.Invoke(service, service, service.DID().String(), blob.AllocateCaveats{
+			Space: space,
+			Blob:  types.Blob{Digest: unsupported, Size: b.Size},
+			Cause: link,
+		})
+		require.NoError(t, err)
+
+		rcpt, err := srv.Run(t.Context(), inv)
+		require.NoError(t, err)
+		typed, err := receipt.Rebind[blob.AllocateOk, fdm.FailureModel](rcpt, blob.AllocateOkType(), fdm.FailureType(), types.Converters...)
+		require.NoError(t, err)
+
+		_, x := result.Unwrap(typed.Out())
+		require.NotNil(t, x.Name)
+		require.Equal(t, "UnsupportedDigest", *x.Name)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		require.NoError(t, blobs.Put(t.Context(), digest, b.Size, bytes.NewReader(data)))
+
+		inv, err := blob.Accept.Invoke(service, service, service.DID().String(), blob.AcceptCaveats{
+			Space: space,
+			Blob:  b,
+			Put:   blob.Promise{UcanAwait: blob.Await{Selector: ".out.ok", Link: link}},
+		})
+		require.NoError(t, err)
+
+		rcpt, err := srv.Run(t.Context(), inv)
+		require.NoError(t, err)
+		typed, err := receipt.Rebind[blob.AcceptOk, fdm.FailureModel](rcpt, blob.AcceptOkType(), fdm.FailureType(), types.Converters...)
+		require.NoError(t, err)
+
+		ok, x := result.Unwrap(typed.Out())
+		require.Zero(t, x)
+
+		// the location commitment is forked off the receipt
+		forks := typed.Fx().Fork()
+		require.Len(t, forks, 1)
+		claim, isInvocation := forks[0].Invocation()
+		require.True(t, isInvocation)
+		require.Equal(t, ok.Site, claim.Link())
+		require.Equal(t, "assert/location", claim.Capabilities()[0].Can())
+
+		_, err = acceptances.Get(t.Context(), digest, space)
+		require.NoError(t, err)
+
+		// the legacy commitment is stored for renewal
+		sp, err := ucantonedid.Parse(space.String())
+		require.NoError(t, err)
+		c, err := commitments.Get(t.Context(), digest, sp)
+		require.NoError(t, err)
+		stored, err := delegation.Extract(c.Legacy)
+		require.NoError(t, err)
+		require.Equal(t, ok.Site, stored.Link())
+	})
+}
+
+// invocations returns the number of invocations of the ability with the
+// outcome counted so far.
+func invocations(t *testing.T, can, outcome string) float64 {
+	t.Helper()
+	mfs, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "padron_ucan_invocations_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["command"] == can && labels["outcome"] == outcome {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
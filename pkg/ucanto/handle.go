@@ -0,0 +1,185 @@
+package ucanto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/transport"
+	thttp "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-ucanto/validator"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
+	"github.com/volmedo/padron/pkg/requestid"
+)
+
+// Limiter limits the rate of invocations by key, like [ratelimit.Limiter].
+type Limiter interface {
+	// AllowAll takes a token for every key, only if all of them have one. It
+	// returns a [*ratelimit.LimitError] otherwise, or a
+	// [*ratelimit.BurstError] if a key is repeated more times than it could
+	// ever be allowed.
+	AllowAll(keys []string) error
+}
+
+// HandleOption configures [Handle].
+type HandleOption func(*handleConfig)
+
+type handleConfig struct {
+	limiter Limiter
+}
+
+// WithLimiter limits the rate of invocations of each issuer, like the UCAN 1.0
+// server does. Requests with a verified invocation over the limit are rejected
+// with 429 Too Many Requests, and those with more invocations from an issuer
+// than its burst with 413 Content Too Large, before any invocation in them is
+// executed. Invocations that fail to verify are not counted, so they cannot
+// use up the tokens of the issuer they claim.
+func WithLimiter(limiter Limiter) HandleOption {
+	return func(cfg *handleConfig) {
+		cfg.limiter = limiter
+	}
+}
+
+// Handle is like [server.Handle], except that receipts carry the ID of the
+// request in their metadata, like UCAN 1.0 receipts do.
+func Handle(ctx context.Context, srv server.ServerView[server.Service], request transport.HTTPRequest, options ...HandleOption) (transport.HTTPResponse, error) {
+	var cfg handleConfig
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	selection, aerr := srv.Codec().Accept(request)
+	if aerr != nil {
+		return thttp.NewResponse(aerr.Status(), io.NopCloser(strings.NewReader(aerr.Error())), aerr.Headers()), nil
+	}
+
+	msg, err := selection.Decoder().Decode(request)
+	if err != nil {
+		return thttp.NewResponse(http.StatusBadRequest, io.NopCloser(strings.NewReader("The server failed to decode the request payload. Please format the payload according to the specified media type.")), nil), nil
+	}
+
+	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(msg.Blocks()))
+	if err != nil {
+		return nil, err
+	}
+
+	var invs []invocation.Invocation
+	for _, link := range msg.Invocations() {
+		inv, err := invocation.NewInvocationView(link, br)
+		if err != nil {
+			return nil, err
+		}
+		invs = append(invs, inv)
+	}
+
+	if cfg.limiter != nil {
+		if res := limit(ctx, srv, cfg.limiter, invs, br); res != nil {
+			return res, nil
+		}
+	}
+
+	id := requestid.FromContext(ctx)
+	var rcpts []receipt.AnyReceipt
+	for _, inv := range invs {
+		rcpt, err := server.Run(ctx, srv, inv)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			// meta values are bound to IPLD nodes, which requires pointers
+			rcpt, err = withMeta(srv.ID(), rcpt, map[string]any{"requestId": &id})
+			if err != nil {
+				return nil, fmt.Errorf("adding request ID to receipt: %w", err)
+			}
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+
+	res, err := message.Build(nil, rcpts)
+	if err != nil {
+		return nil, err
+	}
+	return selection.Encoder().Encode(res)
+}
+
+// limit takes a token for the issuer of every invocation signed by its issuer,
+// and returns a 429 response if any of them is over its limit, or a 413
+// response if any has more invocations than its burst. Only the time bounds
+// and signature of invocations are checked here, and the rest is left to the
+// service methods, so an issuer may be charged for invocations it is not
+// authorized to make, but never for those forged in its name. No token is
+// taken for a rejected request.
+func limit(ctx context.Context, srv server.ServerView[server.Service], limiter Limiter, invs []invocation.Invocation, br blockstore.BlockReader) transport.HTTPResponse {
+	cctx := claimContext{srv.Context()}
+	var issuers []string
+	for _, inv := range invs {
+		prfs, _ := validator.ResolveProofs(ctx, delegation.NewProofsView(inv.Proofs(), br), cctx)
+		if _, err := validator.Validate(ctx, inv, prfs, cctx); err != nil {
+			continue
+		}
+		issuers = append(issuers, inv.Issuer().DID().String())
+	}
+	if len(issuers) == 0 {
+		return nil
+	}
+	err := limiter.AllowAll(issuers)
+	if err == nil {
+		return nil
+	}
+	headers := http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}}
+	status := http.StatusTooManyRequests
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		headers.Set("Retry-After", ratelimit.RetryAfter(limitErr.RetryAfter))
+	} else {
+		status = http.StatusRequestEntityTooLarge
+	}
+	return thttp.NewResponse(status, io.NopCloser(strings.NewReader(err.Error()+"\n")), headers)
+}
+
+// claimContext validates invocations with the context of the server they are
+// sent to, which is their authority.
+type claimContext struct {
+	server.InvocationContext
+}
+
+func (c claimContext) Authority() principal.Verifier {
+	return c.ID().Verifier()
+}
+
+// withMeta issues the receipt again, with the given metadata.
+func withMeta(issuer principal.Signer, rcpt receipt.AnyReceipt, meta map[string]any) (receipt.AnyReceipt, error) {
+	opts := []receipt.Option{
+		receipt.WithMeta(meta),
+		receipt.WithProofs(rcpt.Proofs()),
+		receipt.WithFork(rcpt.Fx().Fork()...),
+		receipt.WithJoin(rcpt.Fx().Join()),
+	}
+	out := result.MapResultR0(
+		rcpt.Out(),
+		func(o ipld.Node) nodeBuilder { return nodeBuilder{o} },
+		func(x ipld.Node) nodeBuilder { return nodeBuilder{x} },
+	)
+	return receipt.Issue(issuer, out, rcpt.Ran(), opts...)
+}
+
+type nodeBuilder struct {
+	node ipld.Node
+}
+
+func (b nodeBuilder) ToIPLD() (ipld.Node, error) {
+	return b.node, nil
+}
@@ -0,0 +1,176 @@
+package ucanto_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/transport"
+	carrequest "github.com/storacha/go-ucanto/transport/car/request"
+	carresponse "github.com/storacha/go-ucanto/transport/car/response"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/ratelimit"
+	"github.com/volmedo/padron/pkg/requestid"
+	"github.com/volmedo/padron/pkg/ucanto"
+	ucantoblob "github.com/volmedo/padron/pkg/ucanto/blob"
+)
+
+func TestHandleRequestID(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+	service, err := ucanto.ToSigner(id)
+	require.NoError(t, err)
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+	space, err := did.Parse(other.DID().String())
+	require.NoError(t, err)
+
+	// allocations for another service are rejected before reaching the blob
+	// service, so none is needed
+	srv, err := server.NewServer(service, ucantoblob.NewBlobAllocateMethod(nil))
+	require.NoError(t, err)
+
+	digest, err := mh.Sum([]byte("testing 1, 2, 3"), mh.SHA2_256, -1)
+	require.NoError(t, err)
+	inv, err := blob.Allocate.Invoke(service, service, other.DID().String(), blob.AllocateCaveats{
+		Space: space,
+		Blob:  types.Blob{Digest: digest, Size: 15},
+		Cause: cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest)},
+	})
+	require.NoError(t, err)
+
+	handle := func(t *testing.T, reqID string) map[string]any {
+		msg, err := message.Build([]invocation.Invocation{inv}, nil)
+		require.NoError(t, err)
+		req, err := carrequest.Encode(msg)
+		require.NoError(t, err)
+		ctx := t.Context()
+		if reqID != "" {
+			ctx = requestid.WithID(ctx, reqID)
+		}
+
+		res, err := ucanto.Handle(ctx, srv, req)
+		require.NoError(t, err)
+		out, err := carresponse.Decode(res)
+		require.NoError(t, err)
+		link, ok := out.Get(inv.Link())
+		require.True(t, ok)
+		rcpt, ok, err := out.Receipt(link)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// the receipt is still the one for the invocation, signed by the service
+		verified, err := rcpt.VerifySignature(service.Verifier())
+		require.NoError(t, err)
+		require.True(t, verified)
+		_, x := result.Unwrap(rcpt.Out())
+		require.NotNil(t, x)
+		return rcpt.Meta()
+	}
+
+	t.Run("records the request ID in the receipt", func(t *testing.T) {
+		meta := handle(t, "abc123")
+		nd, ok := meta["requestId"].(ipld.Node)
+		require.True(t, ok)
+		id, err := nd.AsString()
+		require.NoError(t, err)
+		require.Equal(t, "abc123", id)
+	})
+
+	t.Run("no metadata without a request ID", func(t *testing.T) {
+		meta := handle(t, "")
+		require.NotContains(t, meta, "requestId")
+	})
+}
+
+func TestHandleRateLimit(t *testing.T) {
+	newSigner := func(t *testing.T) principal.Signer {
+		id, err := ed25519.Generate()
+		require.NoError(t, err)
+		s, err := ucanto.ToSigner(id)
+		require.NoError(t, err)
+		return s
+	}
+	service := newSigner(t)
+	alice := newSigner(t)
+	mallory := newSigner(t)
+	space, err := did.Parse(mallory.DID().String())
+	require.NoError(t, err)
+
+	// allocations for another service are rejected before reaching the blob
+	// service, so none is needed
+	srv, err := server.NewServer(service, ucantoblob.NewBlobAllocateMethod(nil))
+	require.NoError(t, err)
+	limiter := ratelimit.New(0.001, 2)
+
+	digest, err := mh.Sum([]byte("testing 1, 2, 3"), mh.SHA2_256, -1)
+	require.NoError(t, err)
+	invoke := func(t *testing.T, issuer principal.Signer, nonce string) invocation.Invocation {
+		inv, err := blob.Allocate.Invoke(issuer, service, mallory.DID().String(), blob.AllocateCaveats{
+			Space: space,
+			Blob:  types.Blob{Digest: digest, Size: 15},
+			Cause: cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest)},
+		}, delegation.WithNonce(nonce))
+		require.NoError(t, err)
+		return inv
+	}
+	handle := func(t *testing.T, invs ...invocation.Invocation) transport.HTTPResponse {
+		msg, err := message.Build(invs, nil)
+		require.NoError(t, err)
+		req, err := carrequest.Encode(msg)
+		require.NoError(t, err)
+		res, err := ucanto.Handle(t.Context(), srv, req, ucanto.WithLimiter(limiter))
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("does not charge invocations with forged signatures", func(t *testing.T) {
+		forged := invoke(t, impostor{mallory, alice.DID()}, "forged")
+		for range 3 {
+			require.Equal(t, http.StatusOK, handle(t, forged).Status())
+		}
+	})
+
+	t.Run("rejects requests over the burst", func(t *testing.T) {
+		res := handle(t, invoke(t, alice, "1"), invoke(t, alice, "2"), invoke(t, alice, "3"))
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.Status())
+		require.Empty(t, res.Headers().Get("Retry-After"))
+	})
+
+	t.Run("rejects requests over the limit", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, handle(t, invoke(t, alice, "1")).Status())
+
+		res := handle(t, invoke(t, alice, "2"), invoke(t, alice, "3"))
+		require.Equal(t, http.StatusTooManyRequests, res.Status())
+		require.Equal(t, "1000", res.Headers().Get("Retry-After"))
+	})
+
+	t.Run("does not charge rejected requests", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, handle(t, invoke(t, alice, "2")).Status())
+		require.Equal(t, http.StatusTooManyRequests, handle(t, invoke(t, alice, "3")).Status())
+	})
+}
+
+// impostor signs with its key, but claims to be another principal.
+type impostor struct {
+	principal.Signer
+	did did.DID
+}
+
+func (i impostor) DID() did.DID {
+	return i.did
+}
@@ -0,0 +1,91 @@
+// Package ucanto contains the glue needed to serve legacy ucanto (UCAN 0.x)
+// invocations alongside UCAN 1.0.
+package ucanto
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"fmt"
+
+	"github.com/alanshaw/ucantone/principal"
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/alanshaw/ucantone/principal/signer"
+	ucantodid "github.com/storacha/go-ucanto/did"
+	ucantoprincipal "github.com/storacha/go-ucanto/principal"
+	ucantoed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	ucantoverifier "github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	ucantosigner "github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+)
+
+// ToSigner converts a ucantone ed25519 signer into a go-ucanto signer with the
+// same key and DID. Signers that do not expose their private key, such as key
+// agent signers, are wrapped so signing is delegated to them.
+func ToSigner(id principal.Signer) (ucantoprincipal.Signer, error) {
+	w, ok := id.(signer.Unwrapper)
+	if !ok {
+		return keyToSigner(id)
+	}
+
+	key, err := keyToSigner(w.Unwrap())
+	if err != nil {
+		return nil, err
+	}
+	d, err := ucantodid.Parse(id.DID().String())
+	if err != nil {
+		return nil, fmt.Errorf("parsing DID: %w", err)
+	}
+	return ucantosigner.Wrap(key, d)
+}
+
+func keyToSigner(id principal.Signer) (ucantoprincipal.Signer, error) {
+	if id.Code() != ed25519.Code {
+		return nil, fmt.Errorf("unsupported signer code 0x%x, only ed25519 keys are supported", id.Code())
+	}
+	if raw := id.Raw(); raw != nil {
+		return ucantoed25519.FromRaw(crypto_ed25519.NewKeyFromSeed(raw))
+	}
+
+	v, err := ucantoverifier.FromRaw(id.Verifier().Raw())
+	if err != nil {
+		return nil, fmt.Errorf("converting verifier: %w", err)
+	}
+	return &delegatingSigner{id: id, verifier: v}, nil
+}
+
+// delegatingSigner is a go-ucanto signer that signs with a ucantone signer.
+type delegatingSigner struct {
+	id       principal.Signer
+	verifier ucantoprincipal.Verifier
+}
+
+func (s *delegatingSigner) Code() uint64 {
+	return ucantoed25519.Code
+}
+
+func (s *delegatingSigner) SignatureCode() uint64 {
+	return ucantoed25519.SignatureCode
+}
+
+func (s *delegatingSigner) SignatureAlgorithm() string {
+	return ucantoed25519.SignatureAlgorithm
+}
+
+func (s *delegatingSigner) Verifier() ucantoprincipal.Verifier {
+	return s.verifier
+}
+
+func (s *delegatingSigner) DID() ucantodid.DID {
+	return s.verifier.DID()
+}
+
+func (s *delegatingSigner) Encode() []byte {
+	return nil
+}
+
+func (s *delegatingSigner) Raw() []byte {
+	return nil
+}
+
+func (s *delegatingSigner) Sign(msg []byte) signature.SignatureView {
+	return signature.NewSignatureView(signature.NewSignature(ucantoed25519.SignatureCode, s.id.Sign(msg)))
+}
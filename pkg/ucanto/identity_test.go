@@ -0,0 +1,24 @@
+package ucanto_test
+
+import (
+	"testing"
+
+	"github.com/alanshaw/ucantone/principal/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volmedo/padron/pkg/ucanto"
+)
+
+func TestToSigner(t *testing.T) {
+	id, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	signer, err := ucanto.ToSigner(id)
+	require.NoError(t, err)
+
+	require.Equal(t, id.DID().String(), signer.DID().String())
+
+	msg := []byte("testing 1, 2, 3")
+	sig := signer.Sign(msg)
+	require.True(t, id.Verifier().Verify(msg, sig.Raw()))
+}
@@ -0,0 +1,45 @@
+package ucanto
+
+import (
+	"context"
+
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/server/transaction"
+
+	"github.com/volmedo/padron/pkg/metrics"
+	"github.com/volmedo/padron/pkg/tracing"
+)
+
+// Instrument wraps a ucanto service method so each invocation it executes,
+// including its validation, is recorded in a span and counted in the
+// invocation metrics, like UCAN 1.0 invocations.
+func Instrument[O ipld.Builder, X failure.IPLDBuilderFailure](can string, method server.ServiceMethod[O, X]) server.ServiceMethod[O, X] {
+	return func(ctx context.Context, inv invocation.Invocation, iCtx server.InvocationContext) (_ transaction.Transaction[O, X], err error) {
+		ctx, span := tracing.Start(ctx, "ucanto.Execute",
+			tracing.Command(can),
+			tracing.Invocation(inv.Link().String()),
+		)
+		defer func() { tracing.End(span, err) }()
+
+		tx, err := method(ctx, inv, iCtx)
+		o := outcome(tx, err)
+		span.SetAttributes(tracing.Outcome(o))
+		metrics.ObserveInvocation(can, o)
+		return tx, err
+	}
+}
+
+func outcome[O, X any](tx transaction.Transaction[O, X], err error) string {
+	if err != nil || tx == nil {
+		return "error"
+	}
+	return result.MatchResultR1(
+		tx.Out(),
+		func(O) string { return "ok" },
+		func(X) string { return "error" },
+	)
+}